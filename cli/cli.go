@@ -10,16 +10,22 @@ package cli
 import (
 	"bufio"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/essentialkaos/ek/v13/env"
@@ -65,6 +71,7 @@ import (
 	"github.com/essentialkaos/npck/tzst"
 
 	"github.com/essentialkaos/rbinstall/index"
+	"github.com/essentialkaos/rbinstall/sign"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -85,11 +92,26 @@ const (
 	OPT_REINSTALL_UPDATED = "X:reinstall-updated"
 	OPT_GEMS_UPDATE       = "G:gems-update"
 	OPT_REHASH            = "H:rehash"
+	OPT_CACHE_LIST        = "cache-list"
+	OPT_CACHE_CLEAN       = "cache-clean"
 	OPT_GEMS_INSECURE     = "s:gems-insecure"
 	OPT_RUBY_VERSION      = "r:ruby-version"
+	OPT_FROM_FILE         = "F:from-file"
+	OPT_SYNC              = "sync"
+	OPT_VERIFY            = "verify"
+	OPT_PRUNE             = "prune"
+	OPT_UPGRADE           = "upgrade"
+	OPT_UPDATE_REFS       = "update-refs"
+	OPT_REMOVE_OLD        = "remove-old"
+	OPT_MIGRATE_GEMS      = "migrate-gems"
+	OPT_YES               = "y:yes"
+	OPT_DRY_RUN           = "D:dry-run"
+	OPT_ALLOW_EOL         = "E:allow-eol"
+	OPT_INDEX             = "I:index"
 	OPT_INFO              = "i:info"
 	OPT_ALL               = "a:all"
 	OPT_PAGER             = "P:pager"
+	OPT_FORMAT            = "f:format"
 	OPT_NO_COLOR          = "nc:no-color"
 	OPT_NO_PROGRESS       = "np:no-progress"
 	OPT_HELP              = "h:help"
@@ -102,25 +124,37 @@ const (
 
 // List of supported config values
 const (
-	MAIN_TMP_DIR          = "main:tmp-dir"
-	STORAGE_URL           = "storage:url"
-	PROXY_ENABLED         = "proxy:enabled"
-	PROXY_URL             = "proxy:url"
-	RBENV_DIR             = "rbenv:dir"
-	RBENV_ALLOW_OVERWRITE = "rbenv:allow-overwrite"
-	RBENV_ALLOW_UNINSTALL = "rbenv:allow-uninstall"
-	RBENV_MAKE_ALIAS      = "rbenv:make-alias"
-	GEMS_RUBYGEMS_UPDATE  = "gems:rubygems-update"
-	GEMS_RUBYGEMS_VERSION = "gems:rubygems-version"
-	GEMS_ALLOW_UPDATE     = "gems:allow-update"
-	GEMS_NO_DOCUMENT      = "gems:no-document"
-	GEMS_SOURCE           = "gems:source"
-	GEMS_SOURCE_SECURE    = "gems:source-secure"
-	GEMS_INSTALL          = "gems:install"
-	LOG_DIR               = "log:dir"
-	LOG_FILE              = "log:file"
-	LOG_MODE              = "log:mode"
-	LOG_LEVEL             = "log:level"
+	MAIN_TMP_DIR           = "main:tmp-dir"
+	MAIN_CACHE_DIR         = "main:cache-dir"
+	MAIN_CACHE_SIZE        = "main:cache-size"
+	STORAGE_URL            = "storage:url"
+	STORAGE_MIRRORS        = "storage:mirrors"
+	STORAGE_KEYS           = "storage:keys"
+	STORAGE_ALLOW_UNSIGNED = "storage:allow-unsigned"
+	STORAGE_RETRIES        = "storage:retries"
+	STORAGE_RETRY_DELAY    = "storage:retry-delay"
+	STORAGE_TIMEOUT        = "storage:timeout"
+	PROXY_ENABLED          = "proxy:enabled"
+	PROXY_URL              = "proxy:url"
+	RBENV_DIR              = "rbenv:dir"
+	RBENV_ALLOW_OVERWRITE  = "rbenv:allow-overwrite"
+	RBENV_ALLOW_UNINSTALL  = "rbenv:allow-uninstall"
+	RBENV_MAKE_ALIAS       = "rbenv:make-alias"
+	RBENV_LOCK_TIMEOUT     = "rbenv:lock-timeout"
+	PRUNE_PROJECT_ROOTS    = "prune:project-roots"
+	PRUNE_SCAN_DEPTH       = "prune:scan-depth"
+	GEMS_RUBYGEMS_UPDATE   = "gems:rubygems-update"
+	GEMS_RUBYGEMS_VERSION  = "gems:rubygems-version"
+	GEMS_ALLOW_UPDATE      = "gems:allow-update"
+	GEMS_NO_DOCUMENT       = "gems:no-document"
+	GEMS_SOURCE            = "gems:source"
+	GEMS_SOURCE_SECURE     = "gems:source-secure"
+	GEMS_INSTALL           = "gems:install"
+	GEMS_WORKERS           = "gems:workers"
+	LOG_DIR                = "log:dir"
+	LOG_FILE               = "log:file"
+	LOG_MODE               = "log:mode"
+	LOG_LEVEL              = "log:level"
 )
 
 // INDEX_NAME is name of index file
@@ -135,6 +169,9 @@ const NONE_VERSION = "- none -"
 // DEFAULT_CATEGORY_SIZE is default category column size
 const DEFAULT_CATEGORY_SIZE = 24
 
+// MAX_RETRY_DELAY is max delay between download retries
+const MAX_RETRY_DELAY = time.Minute
+
 // Default arch names
 const (
 	ARCH_X32 = "x32"
@@ -144,6 +181,16 @@ const (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// storageIndex contains index fetched from storage
+type storageIndex struct {
+	Index  *index.Index
+	URL    string
+	Err    error
+	SigErr error
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 var optMap = options.Map{
 	OPT_REINSTALL:         {Type: options.BOOL, Conflicts: OPT_UNINSTALL},
 	OPT_UNINSTALL:         {Type: options.BOOL, Conflicts: OPT_REINSTALL},
@@ -151,10 +198,25 @@ var optMap = options.Map{
 	OPT_GEMS_UPDATE:       {Type: options.BOOL},
 	OPT_GEMS_INSECURE:     {Type: options.BOOL},
 	OPT_RUBY_VERSION:      {Type: options.BOOL},
+	OPT_FROM_FILE:         {Conflicts: []string{OPT_UNINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED}},
+	OPT_ALLOW_EOL:         {Type: options.BOOL},
+	OPT_DRY_RUN:           {Type: options.BOOL, Conflicts: []string{OPT_REHASH, OPT_CACHE_CLEAN}},
+	OPT_SYNC:              {Conflicts: []string{OPT_FROM_FILE, OPT_UNINSTALL, OPT_REINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED}},
+	OPT_INDEX:             {},
 	OPT_REHASH:            {Type: options.BOOL},
+	OPT_CACHE_LIST:        {Type: options.BOOL, Conflicts: OPT_CACHE_CLEAN},
+	OPT_CACHE_CLEAN:       {Type: options.BOOL, Conflicts: OPT_CACHE_LIST},
 	OPT_ALL:               {Type: options.BOOL},
 	OPT_INFO:              {Type: options.BOOL},
+	OPT_VERIFY:            {Type: options.BOOL, Conflicts: []string{OPT_FROM_FILE, OPT_SYNC, OPT_UNINSTALL, OPT_REINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED, OPT_DRY_RUN}},
+	OPT_PRUNE:             {Type: options.BOOL, Conflicts: []string{OPT_FROM_FILE, OPT_SYNC, OPT_VERIFY, OPT_UNINSTALL, OPT_REINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED}},
+	OPT_UPGRADE:           {Type: options.BOOL, Conflicts: []string{OPT_FROM_FILE, OPT_SYNC, OPT_VERIFY, OPT_PRUNE, OPT_UNINSTALL, OPT_REINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED}},
+	OPT_UPDATE_REFS:       {Type: options.BOOL, Bound: OPT_UPGRADE},
+	OPT_REMOVE_OLD:        {Type: options.BOOL, Bound: OPT_UPGRADE},
+	OPT_MIGRATE_GEMS:      {Type: options.BOOL, Conflicts: []string{OPT_FROM_FILE, OPT_SYNC, OPT_VERIFY, OPT_PRUNE, OPT_UPGRADE, OPT_UNINSTALL, OPT_REINSTALL, OPT_GEMS_UPDATE, OPT_REINSTALL_UPDATED}},
+	OPT_YES:               {Type: options.BOOL},
 	OPT_PAGER:             {Type: options.BOOL},
+	OPT_FORMAT:            {},
 	OPT_NO_COLOR:          {Type: options.BOOL},
 	OPT_NO_PROGRESS:       {Type: options.BOOL},
 	OPT_HELP:              {Type: options.BOOL},
@@ -166,6 +228,8 @@ var optMap = options.Map{
 }
 
 var repoIndex *index.Index
+var repoIndexSigErr error
+var repoStorageURL string
 var temp *tmp.Temp
 var currentUser *system.User
 var runDate time.Time
@@ -205,6 +269,7 @@ func Run(gitRev string, gomod []byte) {
 	}
 
 	configureUI()
+	configureOutputFormat()
 
 	switch {
 	case options.Has(OPT_COMPLETION):
@@ -239,9 +304,14 @@ func Run(gitRev string, gomod []byte) {
 
 	prepare()
 
-	if options.GetB(OPT_REHASH) {
+	switch {
+	case options.GetB(OPT_REHASH):
 		rehashShims()
-	} else {
+	case options.GetB(OPT_CACHE_LIST):
+		listCache()
+	case options.GetB(OPT_CACHE_CLEAN):
+		cleanCache()
+	default:
 		fetchIndex()
 		process(args)
 	}
@@ -356,6 +426,19 @@ func checkPerms() {
 	}
 }
 
+// setupAction checks permissions and prepares logging, temporary data and lock
+// for action which modifies rbenv data (does nothing in dry-run mode)
+func setupAction() {
+	if options.GetB(OPT_DRY_RUN) {
+		return
+	}
+
+	checkPerms()
+	setupLogger()
+	setupTemp()
+	acquireLock()
+}
+
 // setupLogger setup logging subsystem
 func setupLogger() {
 	err := log.Set(knf.GetS(LOG_FILE), knf.GetM(LOG_MODE))
@@ -395,8 +478,20 @@ func validateConfig() {
 		{STORAGE_URL, knfv.Set, nil},
 
 		{STORAGE_URL, knfn.URL, nil},
+		{STORAGE_MIRRORS, validateURLList, nil},
+
+		{STORAGE_RETRIES, knfv.TypeNum, nil},
+		{STORAGE_RETRIES, knfv.InRange, knfv.Range{0, 100}},
+		{STORAGE_RETRY_DELAY, knfv.TypeDur, nil},
+		{STORAGE_TIMEOUT, knfv.TypeDur, nil},
+		{RBENV_LOCK_TIMEOUT, knfv.TypeDur, nil},
+		{GEMS_WORKERS, knfv.TypeNum, nil},
+		{GEMS_WORKERS, knfv.InRange, knfv.Range{1, 32}},
+		{PRUNE_SCAN_DEPTH, knfv.TypeNum, nil},
+		{PRUNE_SCAN_DEPTH, knfv.InRange, knfv.Range{1, 32}},
 
 		{MAIN_TMP_DIR, knff.Perms, "DWX"},
+		{MAIN_CACHE_SIZE, knfv.TypeSize, nil},
 
 		{LOG_LEVEL, knfv.SetToAnyIgnoreCase, log.Levels()},
 	})
@@ -412,73 +507,296 @@ func validateConfig() {
 	}
 }
 
+// validateURLList validates list of URLs
+func validateURLList(config knf.IConfig, prop string, value any) error {
+	for _, u := range config.GetL(prop) {
+		_, err := url.ParseRequestURI(u)
+
+		if err != nil {
+			return fmt.Errorf("%q is not a valid URL address: %v", u, err)
+		}
+	}
+
+	return nil
+}
+
 // fetchIndex download index from remote repository
 func fetchIndex() {
+	if options.Has(OPT_INDEX) {
+		fetchLocalIndex(options.GetS(OPT_INDEX))
+		return
+	}
+
+	var indexes []*storageIndex
+	var wg sync.WaitGroup
+
+	for _, storageURL := range getStorageURLs() {
+		si := &storageIndex{URL: storageURL}
+		indexes = append(indexes, si)
+
+		wg.Add(1)
+
+		go func() {
+			si.Fetch()
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+
+	var freshest *storageIndex
+
+	for _, si := range indexes {
+		if si.Err != nil {
+			if len(indexes) > 1 {
+				printWarn("Can't fetch repository index from %s: %v", si.URL, si.Err)
+			}
+
+			continue
+		}
+
+		if freshest == nil || si.IsFresherThan(freshest) {
+			freshest = si
+		}
+	}
+
+	if freshest == nil {
+		printErrorAndExit("Can't fetch repository index: %v", indexes[0].Err)
+	}
+
+	repoIndex, repoIndexSigErr, repoStorageURL = freshest.Index, freshest.SigErr, freshest.URL
+}
+
+// fetchLocalIndex reads index from local file
+func fetchLocalIndex(file string) {
+	indexData, err := os.ReadFile(file)
+
+	if err != nil {
+		printErrorAndExit("Can't fetch repository index: %v", err)
+	}
+
+	repoIndex, err = decodeIndex(indexData)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	sigData, err := os.ReadFile(file + sign.EXTENSION)
+
+	if err != nil {
+		repoIndexSigErr = fmt.Errorf("Can't fetch index signature: %w", err)
+	} else {
+		repoIndexSigErr = sign.Verify(indexData, string(sigData), knf.GetL(STORAGE_KEYS))
+	}
+
+	repoStorageURL = knf.GetS(STORAGE_URL)
+}
+
+// decodeIndex decodes index data
+func decodeIndex(data []byte) (*index.Index, error) {
+	i := index.NewIndex()
+	err := json.Unmarshal(data, i)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode repository index JSON: %w", err)
+	}
+
+	i.Sort()
+
+	return i, nil
+}
+
+// fetchStorageFile fetches file with given name from storage
+func fetchStorageFile(storageURL, name string) ([]byte, error) {
+	if isLocalStorage(storageURL) {
+		return os.ReadFile(path.Join(getLocalStoragePath(storageURL), name))
+	}
+
 	resp, err := req.Request{
-		URL:   knf.GetS(STORAGE_URL) + "/" + INDEX_NAME,
-		Query: req.Query{"r": time.Now().UnixMicro()},
+		URL:     storageURL + "/" + name,
+		Query:   req.Query{"r": time.Now().UnixMicro()},
+		Timeout: knf.GetTD(STORAGE_TIMEOUT),
 	}.Get()
 
 	if err != nil {
-		printErrorAndExit("Can't fetch repository index: %v", err)
+		return nil, err
 	}
 
 	if resp.StatusCode != 200 {
-		printErrorAndExit("Can't fetch repository index: storage return status code %d", resp.StatusCode)
+		resp.Discard()
+		return nil, fmt.Errorf("storage return status code %d", resp.StatusCode)
+	}
+
+	return resp.Bytes()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Fetch fetches and verifies index from storage
+func (si *storageIndex) Fetch() {
+	indexData, err := fetchStorageFile(si.URL, INDEX_NAME)
+
+	if err != nil {
+		si.Err = err
+		return
 	}
 
-	repoIndex = index.NewIndex()
+	si.Index, si.Err = decodeIndex(indexData)
+
+	if si.Err != nil {
+		return
+	}
 
-	err = resp.JSON(repoIndex)
+	sigData, err := fetchStorageFile(si.URL, INDEX_NAME+sign.EXTENSION)
 
 	if err != nil {
-		printErrorAndExit("Can't decode repository index JSON: %v", err)
+		si.SigErr = fmt.Errorf("Can't fetch index signature: %w", err)
+		return
+	}
+
+	si.SigErr = sign.Verify(indexData, string(sigData), knf.GetL(STORAGE_KEYS))
+}
+
+// IsFresherThan returns true if index is fresher than given one
+func (si *storageIndex) IsFresherThan(other *storageIndex) bool {
+	// Index with valid signature is always preferable if unsigned indexes
+	// are not allowed
+	if !knf.GetB(STORAGE_ALLOW_UNSIGNED, false) && (si.SigErr == nil) != (other.SigErr == nil) {
+		return si.SigErr == nil
 	}
 
-	repoIndex.Sort()
+	return si.Index.Meta.Created > other.Index.Meta.Created
 }
 
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // process process command
 func process(args options.Arguments) {
 	var err error
 	var rubyVersion string
+	var isExactVersion bool
+
+	if options.Has(OPT_FROM_FILE) {
+		startAction("install", strutil.Exclude(path.Base(options.GetS(OPT_FROM_FILE)), ".tzst"))
+
+		if options.GetB(OPT_DRY_RUN) {
+			dryRunInstallFromFile(options.GetS(OPT_FROM_FILE))
+			return
+		}
+
+		setupAction()
+		installVersionFromFile(options.GetS(OPT_FROM_FILE))
+		return
+	}
+
+	if options.Has(OPT_SYNC) {
+		startAction("sync", "")
+
+		setupAction()
+
+		syncVersions(options.GetS(OPT_SYNC))
+		return
+	}
+
+	if options.GetB(OPT_PRUNE) {
+		startAction("prune", "")
+
+		setupAction()
+
+		pruneVersions()
+		return
+	}
+
+	if options.GetB(OPT_UPGRADE) {
+		startAction("upgrade", args.Get(0).String())
+
+		setupAction()
+
+		upgradeVersions(args.Get(0).String())
+		return
+	}
+
+	if options.GetB(OPT_MIGRATE_GEMS) {
+		startAction("migrate-gems", args.Get(1).String())
+
+		setupAction()
+
+		migrateVersionGems(args.Get(0).String(), args.Get(1).String())
+		return
+	}
+
+	if options.GetB(OPT_VERIFY) {
+		startAction("verify", args.Get(0).String())
+		verifyVersions(args.Get(0).String())
+		return
+	}
 
 	if len(args) != 0 {
 		rubyVersion = args.Get(0).String()
 	} else if options.GetB(OPT_RUBY_VERSION) {
-		rubyVersion, err = getVersionFromFile()
+		var versionFile string
+
+		rubyVersion, versionFile, err = getVersionFromFile()
 
 		if err != nil {
 			printErrorAndExit(err.Error())
 		}
 
-		fmtc.Printf("{s}Installing version {s*}%s{s} from version file{!}\n\n", rubyVersion)
+		fmtc.Printf("{s}Using version {s*}%s{s} from {s*}%s{!}\n\n", rubyVersion, versionFile)
+
+		isExactVersion = isExactVersionFile(versionFile)
 	}
 
 	if rubyVersion != "" {
+		switch {
+		case options.GetB(OPT_REINSTALL):
+			rubyVersion = resolveInstalledVersion(rubyVersion)
+		case !options.GetB(OPT_UNINSTALL) && !options.GetB(OPT_GEMS_UPDATE) && !isExactVersion:
+			rubyVersion = resolveVersion(rubyVersion)
+		}
+
 		if options.GetB(OPT_INFO) {
 			showDetailedInfo(rubyVersion)
 			return
 		}
 
-		checkPerms()
-		setupLogger()
-		setupTemp()
+		if options.GetB(OPT_DRY_RUN) {
+			dryRunVersionAction(rubyVersion)
+			return
+		}
+
+		setupAction()
+
+		var err error
 
 		switch {
 		case options.GetB(OPT_GEMS_UPDATE):
+			startAction("gems-update", rubyVersion)
 			updateGems(rubyVersion)
 		case options.GetB(OPT_REINSTALL):
-			reinstallVersion(rubyVersion)
+			startAction("reinstall", rubyVersion)
+			err = reinstallVersion(rubyVersion)
 		case options.GetB(OPT_UNINSTALL):
-			uninstallVersion(rubyVersion)
+			startAction("uninstall", rubyVersion)
+			err = uninstallVersion(rubyVersion)
 		default:
-			installVersion(rubyVersion, false)
+			startAction("install", rubyVersion)
+			err = installVersion(rubyVersion, false)
+		}
+
+		if err != nil {
+			printErrorAndExit(err.Error())
 		}
 	} else {
 		switch {
 		case options.GetB(OPT_REINSTALL_UPDATED):
-			reinstallUpdatedVersions()
+			startAction("reinstall-updated", "")
+
+			if options.GetB(OPT_DRY_RUN) {
+				dryRunReinstallUpdated()
+			} else {
+				reinstallUpdatedVersions()
+			}
 		default:
 			listCommand()
 		}
@@ -487,15 +805,23 @@ func process(args options.Arguments) {
 
 // showDetailedInfo shows detailed information about given version
 func showDetailedInfo(rubyVersion string) {
-	info, _, err := getVersionInfo(rubyVersion)
+	info, category, err := getVersionInfo(rubyVersion)
 
 	if err != nil {
 		printErrorAndExit(err.Error())
 	}
 
+	url := fmt.Sprintf("%s/%s/%s", repoStorageURL, info.Path, info.File)
+
+	if outputFormat != "" {
+		record := getVersionRecord(info, category)
+		record.URL = url
+		printFormatted(record)
+		return
+	}
+
 	fmtutil.Separator(true)
 
-	url := fmt.Sprintf("%s/%s/%s", knf.GetS(STORAGE_URL), info.Path, info.File)
 	added := timeutil.Format(time.Unix(info.Added, 0), "%Y/%m/%d %H:%M")
 
 	fmtc.Printfn(" {*}%-16s{!} {s}|{!} %s", "Name", info.Name)
@@ -504,14 +830,35 @@ func showDetailedInfo(rubyVersion string) {
 	fmtc.Printfn(" {*}%-16s{!} {s}|{!} %s", "SHA-256 Checksum", info.Hash)
 	fmtc.Printfn(" {*}%-16s{!} {s}|{!} %s", "Added", added)
 
-	if isVersionInstalled(info.Name) {
-		installDate, _ := fsutil.GetMTime(getVersionPath(info.Name))
+	receipt, _ := getInstallReceipt(info.Name)
+
+	switch {
+	case receipt != nil:
+		installDateStr := timeutil.Format(receipt.Installed, "%Y/%m/%d %H:%M")
+		fmtc.Printfn(
+			" {*}%-16s{!} {s}|{!} Yes {s-}(%s by %s){!}",
+			"Installed", installDateStr, receipt.User,
+		)
+	case isVersionInstalled(info.Name):
+		installDate, _ := getInstallDate(info.Name)
 		installDateStr := timeutil.Format(installDate, "%Y/%m/%d %H:%M")
 		fmtc.Printfn(" {*}%-16s{!} {s}|{!} Yes {s-}(%s){!}", "Installed", installDateStr)
-	} else {
+	default:
 		fmtc.Printfn(" {*}%-16s{!} {s}|{!} No", "Installed")
 	}
 
+	if isVersionInstalled(info.Name) && isVersionOutdated(info) {
+		fmtc.Printfn(" {*}%-16s{!} {s}|{!} {y}Yes{!}", "Outdated")
+	}
+
+	if receipt != nil {
+		if receipt.RubyGems != "" {
+			fmtc.Printfn(" {*}%-16s{!} {s}|{!} %s", "RubyGems", receipt.RubyGems)
+		}
+
+		printReceiptGems(receipt)
+	}
+
 	if info.EOL {
 		fmtc.Printfn(" {*}%-16s{!} {s}|{!} {r}Yes{!}", "EOL")
 	} else {
@@ -537,6 +884,27 @@ func showDetailedInfo(rubyVersion string) {
 	fmtutil.Separator(true)
 }
 
+// printReceiptGems prints info about gems from install receipt
+func printReceiptGems(receipt *installReceipt) {
+	var gems []string
+
+	for gemName := range receipt.Gems {
+		gems = append(gems, gemName)
+	}
+
+	sort.Strings(gems)
+
+	for index, gemName := range gems {
+		name := "Gems"
+
+		if index != 0 {
+			name = ""
+		}
+
+		fmtc.Printfn(" {*}%-16s{!} {s}|{!} %s {s-}(%s){!}", name, gemName, receipt.Gems[gemName])
+	}
+}
+
 // listCommand show list of all available versions
 func listCommand() {
 	dist, arch, err := getSystemInfo()
@@ -546,16 +914,19 @@ func listCommand() {
 	}
 
 	if !repoIndex.HasData(dist, arch) {
-		terminal.Warn(
+		printWarn(
 			"Prebuilt binaries not found for this system (%s/%s)",
 			dist, arch,
 		)
 		exit(1)
 	}
 
-	if useRawOutput {
+	switch {
+	case outputFormat != "":
+		printFormattedListing(dist, arch)
+	case useRawOutput:
 		printRawListing(dist, arch)
-	} else {
+	default:
 		printPrettyListing(dist, arch)
 	}
 }
@@ -672,16 +1043,62 @@ func printRawListing(dist, arch string) {
 }
 
 // installVersion install given version of ruby
-func installVersion(rubyVersion string, reinstall bool) {
+func installVersion(rubyVersion string, reinstall bool) error {
+	info, err := prepareInstall(rubyVersion, reinstall)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := fetchArchive(info)
+
+	if err != nil {
+		return err
+	}
+
+	return installArchive(info, file)
+}
+
+// installVersionFromFile install ruby from given local archive
+func installVersionFromFile(file string) {
+	if !fsutil.CheckPerms("FRS", file) {
+		printErrorAndExit("File %s doesn't exist, empty or not readable", file)
+	}
+
+	if !strings.HasSuffix(file, ".tzst") {
+		printErrorAndExit("File %s is not a Ruby archive (.tzst)", file)
+	}
+
+	reinstall := options.GetB(OPT_REINSTALL)
+
+	if reinstall && !knf.GetB(RBENV_ALLOW_OVERWRITE, false) {
+		printErrorAndExit("Reinstalling is not allowed")
+	}
+
+	info, err := prepareInstall(strutil.Exclude(path.Base(file), ".tzst"), reinstall)
+
+	if err == nil {
+		err = installArchive(info, file)
+	}
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+}
+
+// prepareInstall finds info about given version and checks that it can be installed
+func prepareInstall(rubyVersion string, reinstall bool) (*index.VersionInfo, error) {
 	if isVersionInstalled(rubyVersion) && !reinstall {
-		terminal.Warn("Version %s already installed", rubyVersion)
+		printWarn("Version %s already installed", rubyVersion)
 		exit(0)
 	}
 
+	checkIndexSignature()
+
 	info, category, err := getVersionInfo(rubyVersion)
 
 	if err != nil {
-		printErrorAndExit(err.Error())
+		return nil, err
 	}
 
 	progress.DefaultSettings.BarFgColorTag = "{" + categoryColor[category] + "}"
@@ -689,44 +1106,87 @@ func installVersion(rubyVersion string, reinstall bool) {
 	fmtc.AddColor("category", "{"+categoryColor[category]+"}")
 
 	checkRBEnv()
-	checkDependencies(info, category)
+
+	err = validateDependencies(info, category)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if !fsutil.IsExist(getUnpackDirPath()) {
 		err = os.Mkdir(getUnpackDirPath(), 0770)
 
 		if err != nil {
-			printErrorAndExit("Can't create directory for unpacking data: %v", err)
+			return nil, fmt.Errorf("Can't create directory for unpacking data: %v", err)
 		}
 	} else {
 		os.Remove(path.Join(getUnpackDirPath(), info.Name))
 	}
 
-	// //////////////////////////////////////////////////////////////////////////////// //
+	return info, nil
+}
 
-	var file string
+// fetchArchive fetches archive with given version from cache or storage
+func fetchArchive(info *index.VersionInfo) (string, error) {
+	var err error
 
-	if !noProgress {
+	file := getCachedArchive(info)
+
+	if file != "" {
+		startTask("Fetching {*}{?category}%s{!} from cache", info.Name)
+		doneTask(true)
+		return file, nil
+	}
+
+	var storageURL string
+
+	if !noProgress && !isLocalStorage(repoStorageURL) {
 		fmtc.Printfn("Fetching {*}{?category}%s{!} from storage…", info.Name)
-		file, err = downloadFile(info)
+		file, storageURL, err = downloadFile(info)
 	} else {
-		spinner.Show("Fetching {*}{?category}%s{!} from storage", info.Name)
-		file, err = downloadFile(info)
-		spinner.Done(err == nil)
+		startTask("Fetching {*}{?category}%s{!} from storage", info.Name)
+		file, storageURL, err = downloadFile(info)
+		doneTask(err == nil)
 	}
 
 	if err != nil {
-		printErrorAndExit(err.Error())
+		return "", err
 	}
 
-	// //////////////////////////////////////////////////////////////////////////////// //
+	err = cacheArchive(info, file, storageURL)
+
+	if err != nil {
+		printWarn("Can't save %s to cache: %v", info.File, err)
+	}
+
+	return file, nil
+}
+
+// installArchive unpacks archive with given version and installs it to rbenv
+func installArchive(info *index.VersionInfo, file string) (err error) {
+	// Changes made by failed install must be reverted
+	defer func() {
+		if err != nil {
+			rollbackInstall()
+		}
+	}()
 
-	spinner.Show("Checking SHA-1 checksum")
+	startTask("Checking SHA-1 checksum")
 	err = checkHashTaskHandler(file, info.Hash)
-	spinner.Done(err == nil)
+	doneTask(err == nil)
 
 	if err != nil {
-		fmtc.NewLine()
-		printErrorAndExit(err.Error())
+		return err
+	}
+
+	if !knf.GetB(STORAGE_ALLOW_UNSIGNED, false) {
+		startTask("Checking signature")
+		err = checkSignatureTaskHandler(info)
+		doneTask(err == nil)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
@@ -735,72 +1195,101 @@ func installVersion(rubyVersion string, reinstall bool) {
 		fmtc.Printfn("Unpacking {*}{?category}%s{!} data…", info.Name)
 		err = unpackFile(file, getUnpackDirPath())
 	} else {
-		spinner.Show("Unpacking {*}{?category}%s{!} data", info.Name)
+		startTask("Unpacking {*}{?category}%s{!} data", info.Name)
 		err = unpackFile(file, getUnpackDirPath())
-		spinner.Done(err == nil)
+		doneTask(err == nil)
 	}
 
 	if err != nil {
-		printErrorAndExit(err.Error())
+		return err
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	spinner.Show("Checking binary")
+	startTask("Checking binary")
 	err = checkBinaryTaskHandler(info.Name, getUnpackDirPath())
-	spinner.Done(err == nil)
+	doneTask(err == nil)
 
 	if err != nil {
-		fmtc.NewLine()
-		printErrorAndExit(err.Error())
+		return err
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	if isVersionInstalled(info.Name) {
-		err = os.RemoveAll(getVersionPath(info.Name))
+	tx, err := startInstallTransaction(info.Name)
 
-		if err != nil {
-			printErrorAndExit("Can't remove %s: %v", info.Name, err)
-		}
+	if err != nil {
+		return err
 	}
 
-	err = os.Rename(path.Join(getUnpackDirPath(), info.Name), getVersionPath(info.Name))
+	err = tx.Apply(path.Join(getUnpackDirPath(), info.Name))
 
 	if err != nil {
-		printErrorAndExit("Can't move unpacked data to rbenv directory: %v", err)
+		return fmt.Errorf("Can't move unpacked data to rbenv directory: %v", err)
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	if knf.GetB(GEMS_RUBYGEMS_UPDATE) && strutil.HasPrefixAny(info.Name, "1", "2", "3") {
+	err = checkCanceled()
+
+	if err != nil {
+		return err
+	}
+
+	gs := getGemSet(info.Name)
+
+	if gs.RubyGemsUpdate && strutil.HasPrefixAny(info.Name, "1", "2", "3") {
 		rgVersion := getAdvisableRubyGemsVersion(info.Name)
 
-		spinner.Show("Updating RubyGems to %s", formatGemVersion(rgVersion))
+		startTask("Updating RubyGems to %s", formatGemVersion(rgVersion))
 		err = updateRubygemsTaskHandler(info.Name, rgVersion)
-		spinner.Done(err == nil)
+		doneTask(err == nil)
 
 		if err != nil {
-			terminal.Warn(err.Error())
+			return err
 		}
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	if knf.GetS(GEMS_INSTALL) != "" {
-		for _, gem := range strings.Split(knf.GetS(GEMS_INSTALL), " ") {
+	if len(gs.Install) > 1 && knf.GetI(GEMS_WORKERS, 1) > 1 {
+		errs := installGemsParallel(info.Name, gs.Install, knf.GetI(GEMS_WORKERS, 1))
+
+		if len(errs) != 0 {
+			fmtc.NewLine()
+
+			for _, err := range errs[1:] {
+				printWarn(err.Error())
+			}
+
+			return errs[0]
+		}
+	} else if len(gs.Install) != 0 {
+		for _, gem := range gs.Install {
+			err = checkCanceled()
+
+			if err != nil {
+				return err
+			}
+
 			gemName, gemVersion := parseGemInfo(gem)
 
-			spinner.Show("Installing %s (%s)", gemName, formatGemVersion(gemVersion))
+			startTask("Installing %s (%s)", gemName, formatGemVersion(gemVersion))
 			_, err = installGemTaskHandler(info.Name, gemName, gemVersion)
-			spinner.Done(err == nil)
+			doneTask(err == nil)
 
 			if err != nil {
-				terminal.Warn(err.Error())
+				return err
 			}
 		}
 	}
 
+	err = checkCanceled()
+
+	if err != nil {
+		return err
+	}
+
 	// //////////////////////////////////////////////////////////////////////////////// //
 
 	var cleanVersionName string
@@ -810,83 +1299,123 @@ func installVersion(rubyVersion string, reinstall bool) {
 		cleanVersionName = getNameWithoutPatchLevel(info.Name)
 
 		if knf.GetB(RBENV_MAKE_ALIAS, false) && !fsutil.IsExist(getVersionPath(cleanVersionName)) {
-			err = os.Symlink(getVersionPath(info.Name), getVersionPath(cleanVersionName))
+			err = tx.CreateAlias(cleanVersionName)
 
 			if err != nil {
 				fmtc.Println("{r}✖  {!}Creating alias")
-				terminal.Warn(err.Error())
-			} else {
-				fmtc.Println("{g}✔  {!}Creating alias")
-				aliasCreated = true
+				return err
 			}
+
+			fmtc.Println("{g}✔  {!}Creating alias")
+			aliasCreated = true
 		}
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	rehashShims()
+	startTask("Verifying installation")
+	err = verifyInstallTaskHandler(info.Name)
+	doneTask(err == nil)
+
+	if err != nil {
+		return err
+	}
+
+	err = runRehashTask()
+
+	if err == nil {
+		err = checkCanceled()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		printWarn("Can't remove backup of previous installation: %v", err)
+	}
+
+	err = registerVersion(info)
+
+	if err != nil {
+		printWarn("Can't add %s to install registry: %v", info.Name, err)
+	}
 
 	fmtc.NewLine()
 
+	source := fmt.Sprintf(
+		"source: %s/%s/%s | index: %s | SHA-256: %s",
+		repoStorageURL, info.Path, info.File, getIndexUUID(), info.Hash,
+	)
+
 	if aliasCreated {
-		log.Info("[%s] Installed version %s as %s", currentUser.RealName, info.Name, cleanVersionName)
+		log.Info("[%s] Installed version %s as %s (%s)", currentUser.RealName, info.Name, cleanVersionName, source)
 		fmtc.Printfn("{g}Version {*}%s{!*} successfully installed as {*}%s{!}", info.Name, cleanVersionName)
 	} else {
-		log.Info("[%s] Installed version %s", currentUser.RealName, info.Name)
+		log.Info("[%s] Installed version %s (%s)", currentUser.RealName, info.Name, source)
 		fmtc.Printfn("{g}Version {*}%s{!*} successfully installed{!}", info.Name)
 	}
+
+	return nil
 }
 
 // uninstallVersion uninstall given version of ruby
-func uninstallVersion(rubyVersion string) {
+func uninstallVersion(rubyVersion string) error {
 	if !knf.GetB(RBENV_ALLOW_UNINSTALL, false) {
-		printErrorAndExit("Uninstalling is not allowed")
+		return fmt.Errorf("Uninstalling is not allowed")
 	}
 
-	info, _, err := getVersionInfo(rubyVersion)
+	versionName, err := getInstalledVersionName(rubyVersion)
 
 	if err != nil {
-		printErrorAndExit(err.Error())
+		return err
 	}
 
-	if !isVersionInstalled(info.Name) {
-		printErrorAndExit("Version %s is not installed", rubyVersion)
+	if !isVersionRegistered(versionName) {
+		printWarn("Origin of version %s is unknown (there is no install receipt for it)", versionName)
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	spinner.Show("Uninstalling %s", rubyVersion)
-	err = uninstallTaskHandler(info.Name)
-	spinner.Done(err == nil)
+	startTask("Uninstalling %s", rubyVersion)
+	err = uninstallTaskHandler(versionName)
+	doneTask(err == nil)
 
 	if err != nil {
-		fmtc.NewLine()
-		printErrorAndExit(err.Error())
+		return err
 	}
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	rehashShims()
+	err = runRehashTask()
+
+	if err != nil {
+		return err
+	}
 
 	fmtc.NewLine()
 
-	log.Info("[%s] Uninstalled version %s", currentUser.RealName, info.Name)
+	log.Info("[%s] Uninstalled version %s", currentUser.RealName, versionName)
 	fmtc.Printfn("{g}Version {*}%s{!*} successfully uninstalled{!}", rubyVersion)
+
+	return nil
 }
 
 // reinstallVersion reinstalls given version of ruby
-func reinstallVersion(rubyVersion string) {
+func reinstallVersion(rubyVersion string) error {
 	if !isVersionInstalled(rubyVersion) {
-		printErrorAndExit("Version %s in not installed", rubyVersion)
+		return fmt.Errorf("Version %s in not installed", rubyVersion)
 	}
 
 	if !knf.GetB(RBENV_ALLOW_OVERWRITE, false) {
-		printErrorAndExit("Reinstalling is not allowed")
+		return fmt.Errorf("Reinstalling is not allowed")
 	}
 
-	terminal.Warn("Reinstalling %s…\n", rubyVersion)
+	printWarn("Reinstalling %s…\n", rubyVersion)
 
-	installVersion(rubyVersion, true)
+	return installVersion(rubyVersion, true)
 }
 
 // reinstallUpdatedVersions reinstalls all rebuilt versions
@@ -894,13 +1423,11 @@ func reinstallUpdatedVersions() {
 	installed := getInstalledVersionsMap()
 
 	if len(installed) == 0 {
-		terminal.Warn("There is no installed versions")
+		printWarn("There is no installed versions")
 		return
 	}
 
-	checkPerms()
-	setupLogger()
-	setupTemp()
+	setupAction()
 
 	var hasUpdates bool
 
@@ -911,15 +1438,7 @@ func reinstallUpdatedVersions() {
 			continue
 		}
 
-		installDate, err := fsutil.GetMTime(getVersionPath(rubyVersion))
-
-		if err != nil {
-			fmtc.NewLine()
-			terminal.Error("Can't check install date of version %s: %v", rubyVersion, err)
-			continue
-		}
-
-		if installDate.Unix() >= info.Added {
+		if !isVersionOutdated(info) {
 			continue
 		}
 
@@ -927,9 +1446,17 @@ func reinstallUpdatedVersions() {
 			fmtc.NewLine()
 		}
 
-		terminal.Warn("Reinstalling %s…\n", rubyVersion)
+		printWarn("Reinstalling %s…\n", rubyVersion)
 
-		installVersion(rubyVersion, true)
+		startSubAction("reinstall", rubyVersion)
+
+		err = installVersion(rubyVersion, true)
+
+		if err != nil {
+			printErrorAndExit(err.Error())
+		}
+
+		finishSubAction()
 
 		hasUpdates = true
 	}
@@ -941,9 +1468,7 @@ func reinstallUpdatedVersions() {
 
 // rehashShims run 'rbenv rehash' command
 func rehashShims() {
-	spinner.Show("Rehashing")
-	err := rehashTaskHandler()
-	spinner.Done(err == nil)
+	err := runRehashTask()
 
 	if err != nil {
 		fmtc.NewLine()
@@ -951,6 +1476,15 @@ func rehashShims() {
 	}
 }
 
+// runRehashTask runs rehash task and returns error if rehash failed
+func runRehashTask() error {
+	startTask("Rehashing")
+	err := rehashTaskHandler()
+	doneTask(err == nil)
+
+	return err
+}
+
 // uninstallTaskHandler remove data for given ruby version
 func uninstallTaskHandler(versionName string) error {
 	versionsDir := getRBEnvVersionsPath()
@@ -976,7 +1510,7 @@ func uninstallTaskHandler(versionName string) error {
 		}
 	}
 
-	return nil
+	return unregisterVersion(versionName)
 }
 
 // checkHashTaskHandler check archive checksum
@@ -990,6 +1524,17 @@ func checkHashTaskHandler(filePath, fileHash string) error {
 	return nil
 }
 
+// checkSignatureTaskHandler check archive signature
+func checkSignatureTaskHandler(info *index.VersionInfo) error {
+	err := sign.Verify(info.SignatureData(), info.Signature, knf.GetL(STORAGE_KEYS))
+
+	if err != nil {
+		return fmt.Errorf("Can't verify %s signature: %w", info.File, err)
+	}
+
+	return nil
+}
+
 // checkBinaryTaskHandler run and check installer binary
 func checkBinaryTaskHandler(args ...string) error {
 	version, unpackDir := args[0], args[1]
@@ -999,6 +1544,22 @@ func checkBinaryTaskHandler(args ...string) error {
 	return exec.Command(binary, "--version").Start()
 }
 
+// verifyInstallTaskHandler checks that installed version works
+func verifyInstallTaskHandler(rubyVersion string) error {
+	binary := path.Join(getVersionPath(rubyVersion), "bin/ruby")
+	output, err := exec.Command(binary, "--version").CombinedOutput()
+
+	if err == nil {
+		return nil
+	}
+
+	if len(output) != 0 {
+		return fmt.Errorf("Can't run installed version: %s", strings.TrimRight(string(output), "\r\n"))
+	}
+
+	return fmt.Errorf("Can't run installed version: %w", err)
+}
+
 // installGemTaskHandler run gems installing command
 func installGemTaskHandler(rubyVersion, gem, gemVersion string) (string, error) {
 	// Do not install the latest version of bundler on Ruby < 2.3.0
@@ -1072,15 +1633,50 @@ func updateGems(rubyVersion string) {
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	if knf.GetB(GEMS_RUBYGEMS_UPDATE) {
+	gs := getGemSet(rubyVersion)
+
+	if gs.RubyGemsUpdate {
 		rgVersion := getAdvisableRubyGemsVersion(rubyVersion)
 
-		spinner.Show("Updating RubyGems to %s", rgVersion)
+		startTask("Updating RubyGems to %s", rgVersion)
 		err = updateRubygemsTaskHandler(rubyVersion, rgVersion)
-		spinner.Done(err == nil)
+		doneTask(err == nil)
 
 		if err != nil {
-			terminal.Warn(err.Error())
+			printWarn(err.Error())
+		}
+
+		installed = true
+	}
+
+	// //////////////////////////////////////////////////////////////////////////////// //
+
+	if len(gs.Install) != 0 {
+		var installedVersion string
+
+		for _, gem := range gs.Install {
+			gemName, gemVersion := parseGemInfo(gem)
+
+			if isGemInstalled(rubyVersion, gemName) {
+				startTask("Updating %s (%s)", gemName, formatGemVersion(gemVersion))
+				installedVersion, err = updateGemTaskHandler(rubyVersion, gemName, gemVersion)
+			} else {
+				startTask("Installing %s (%s)", gemName, formatGemVersion(gemVersion))
+				installedVersion, err = installGemTaskHandler(rubyVersion, gemName, gemVersion)
+			}
+
+			doneTask(err == nil)
+
+			if err == nil {
+				if installedVersion != "" {
+					log.Info(
+						"[%s]Gem %s updated to version %s for %s",
+						currentUser.RealName, gem, installedVersion, rubyVersion,
+					)
+				}
+			} else {
+				printWarn(err.Error())
+			}
 		}
 
 		installed = true
@@ -1088,179 +1684,579 @@ func updateGems(rubyVersion string) {
 
 	// //////////////////////////////////////////////////////////////////////////////// //
 
-	if knf.GetS(GEMS_INSTALL) != "" {
-		var installedVersion string
+	if installed {
+		if isVersionRegistered(rubyVersion) {
+			err = updateReceiptGems(rubyVersion)
+
+			if err != nil {
+				printWarn("Can't update install receipt for %s: %v", rubyVersion, err)
+			}
+		}
+
+		rehashShims()
+
+		fmtc.NewLine()
+		fmtc.Println("{g}All gems successfully updated!{!}")
+	} else {
+		fmtc.NewLine()
+		fmtc.Println("{y}There is nothing to update{!}")
+	}
+}
+
+// runGemCmd run some gem command for some version
+func runGemCmd(rubyVersion, cmd, gem, gemVersion string) (string, error) {
+	start := time.Now()
+	gemCmd := getGemCmd(rubyVersion, cmd, gem, gemVersion)
+	gemCmd.Args = append(gemCmd.Args, "--force")
+
+	if knf.GetB(GEMS_NO_DOCUMENT) {
+		gemCmd.Args = append(gemCmd.Args, "--no-document")
+	}
+
+	output, err := gemCmd.CombinedOutput()
+
+	if err == nil {
+		version := parseInstalledGemVersion(string(output), gem)
+
+		if version == "" {
+			version = getInstalledGemVersion(rubyVersion, gem, start)
+		}
+
+		return version, nil
+	}
+
+	actionLog, err := logFailedAction(strings.TrimRight(string(output), "\r\n"))
+
+	if err == nil {
+		switch cmd {
+		case "update":
+			return "", fmtc.Errorf(
+				"Can't update gem %s (%s). Gem command output saved as %s.",
+				gem, formatGemVersion(gemVersion), actionLog,
+			)
+		default:
+			return "", fmtc.Errorf(
+				"Can't install gem %s (%s). Gem command output saved as %s.",
+				gem, formatGemVersion(gemVersion), actionLog,
+			)
+		}
+	}
+
+	switch cmd {
+	case "update":
+		return "", fmtc.Errorf("Can't update gem %s (%s)", gem, formatGemVersion(gemVersion))
+	default:
+		return "", fmtc.Errorf("Can't install gem %s (%s)", gem, formatGemVersion(gemVersion))
+	}
+}
+
+// getGemCmd returns gem command for given version
+func getGemCmd(rubyVersion, cmd, gem, gemVersion string) *exec.Cmd {
+	rubyPath := getVersionPath(rubyVersion)
+	gemCmd := exec.Command(rubyPath+"/bin/ruby", rubyPath+"/bin/gem", cmd, gem)
+
+	// Version with "=" prefix is exact version (e.g. "=1.2")
+	switch {
+	case gemVersion == "":
+		// latest version
+	case strings.HasPrefix(gemVersion, "="), strings.Count(gemVersion, ".") >= 2:
+		gemCmd.Args = append(gemCmd.Args, "--version", gemVersion)
+	default:
+		gemCmd.Args = append(gemCmd.Args, "--version", fmt.Sprintf("~>%s.0", gemVersion))
+	}
+
+	if knf.GetS(GEMS_SOURCE) != "" {
+		gemCmd.Args = append(gemCmd.Args, "--clear-sources", "--source", getGemSourceURL(rubyVersion))
+	}
+
+	return gemCmd
+}
+
+// updateRubygems update rubygems to defined version
+func updateRubygems(rubyVersion, gemVersion string) error {
+	rubyPath := getVersionPath(rubyVersion)
+	gemCmd := exec.Command(rubyPath+"/bin/ruby", rubyPath+"/bin/gem", "update", "--no-document", "--system")
+
+	if gemVersion != "latest" {
+		gemCmd.Args = append(gemCmd.Args, gemVersion)
+	}
+
+	if knf.GetS(GEMS_SOURCE) != "" {
+		gemCmd.Args = append(gemCmd.Args, "--clear-sources", "--source", getGemSourceURL(rubyVersion))
+	}
+
+	output, err := gemCmd.CombinedOutput()
+
+	if err == nil {
+		return nil
+	}
+
+	actionLog, err := logFailedAction(strings.TrimRight(string(output), "\r\n"))
+
+	if err == nil {
+		return fmt.Errorf("Can't update rubygems. Update command output saved as %s", actionLog)
+	}
+
+	return fmt.Errorf("Can't update rubygems")
+}
+
+// downloadFile download file from remote host and returns path to file and URL
+// of storage used for downloading
+func downloadFile(info *index.VersionInfo) (string, string, error) {
+	var err error
+	var file string
+
+	storageURLs := getDownloadStorageURLs()
+
+	for index, storageURL := range storageURLs {
+		if isLocalStorage(storageURL) {
+			file, err = getLocalStorageFile(storageURL, info)
+		} else {
+			file, err = downloadFileFromStorage(storageURL, info, index+1 < len(storageURLs))
+		}
+
+		if err == nil {
+			log.Info("Archive %s fetched from %s", info.File, storageURL)
+			return file, storageURL, nil
+		}
+
+		log.Error("Can't fetch %s from %s: %v", info.File, storageURL, err)
+
+		if index+1 < len(storageURLs) {
+			printWarn(
+				"Can't fetch %s from %s: %v. Trying next mirror…",
+				info.File, storageURL, err,
+			)
+		}
+	}
+
+	return "", "", err
+}
+
+// getLocalStorageFile returns path to archive in local storage
+func getLocalStorageFile(storageURL string, info *index.VersionInfo) (string, error) {
+	file := path.Join(getLocalStoragePath(storageURL), info.Path, info.File)
+
+	if !fsutil.CheckPerms("FRS", file) {
+		return "", fmt.Errorf("File %s doesn't exist, empty or not readable", file)
+	}
+
+	err := checkHashTaskHandler(file, info.Hash)
+
+	if err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+// downloadFileFromStorage downloads file from given remote storage. If storage
+// is unreachable and there are other mirrors, it fails without retries.
+func downloadFileFromStorage(storageURL string, info *index.VersionInfo, hasMirrors bool) (string, error) {
+	tmpDir, err := temp.MkDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	partialFile, err := getPartialFilePath(info)
+
+	if err != nil {
+		return "", err
+	}
+
+	retries := knf.GetI(STORAGE_RETRIES, 3)
+	retryDelay := knf.GetTD(STORAGE_RETRY_DELAY, time.Second)
+
+	for attempt := 0; ; attempt++ {
+		err = fetchFile(storageURL, info, partialFile)
+
+		if err == nil {
+			err = checkHashTaskHandler(partialFile, info.Hash)
+
+			if err != nil {
+				// Data is corrupted, so we have to download it from scratch
+				os.Remove(partialFile)
+			}
+		}
+
+		if err == nil {
+			break
+		}
+
+		if attempt >= retries || (hasMirrors && isConnectionError(err)) {
+			return "", err
+		}
+
+		delay := getRetryDelay(retryDelay, attempt)
+
+		log.Warn("Can't download %s (attempt %d): %v", info.File, attempt+1, err)
+		printWarn(
+			"Can't download %s: %v. Retrying in %s…",
+			info.File, err, timeutil.PrettyDuration(delay),
+		)
+
+		time.Sleep(delay)
+	}
+
+	output := path.Join(tmpDir, info.File)
+	err = os.Rename(partialFile, output)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't move downloaded data: %w", err)
+	}
+
+	return output, nil
+}
+
+// isConnectionError returns true if error is caused by unreachable storage
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// getRetryDelay returns delay before next retry (doubles with every attempt,
+// but never exceeds MAX_RETRY_DELAY)
+func getRetryDelay(baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay
+
+	for range attempt {
+		if delay >= MAX_RETRY_DELAY {
+			break
+		}
+
+		delay *= 2
+	}
+
+	return min(delay, MAX_RETRY_DELAY)
+}
+
+// fetchFile downloads file from storage or resumes downloading of partially
+// downloaded file
+func fetchFile(storageURL string, info *index.VersionInfo, output string) error {
+	fd, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|syscall.O_NOFOLLOW, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	offset, err := fd.Seek(0, io.SeekEnd)
+
+	if err != nil {
+		return err
+	}
+
+	request := req.Request{
+		URL:     storageURL + "/" + info.Path + "/" + info.File,
+		Query:   req.Query{"hash": info.Hash},
+		Timeout: knf.GetTD(STORAGE_TIMEOUT),
+	}
+
+	if offset > 0 {
+		request.Headers = req.Headers{"Range": fmt.Sprintf("bytes=%d-", offset)}
+	}
+
+	resp, err := request.Get()
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+		// Storage doesn't support ranges, so we have to download file from scratch
+		offset = 0
+
+		err = fd.Truncate(0)
+
+		if err == nil {
+			_, err = fd.Seek(0, io.SeekStart)
+		}
+
+		if err != nil {
+			return err
+		}
+
+	case 206:
+		// continue downloading
+
+	case 416:
+		// File already fully downloaded
+		return nil
+
+	default:
+		return fmtc.Errorf("Server return error code %d", resp.StatusCode)
+	}
+
+	if noProgress {
+		_, err = io.Copy(fd, resp.Body)
+	} else {
+		pb := progress.New(offset+resp.ContentLength, "")
+		pb.SetCurrent(offset)
+		pb.Start()
+		_, err = io.Copy(fd, pb.Reader(resp.Body))
+		pb.Finish()
+	}
+
+	return err
+}
+
+// getPartialFilePath returns path to file used for partially downloaded data
+func getPartialFilePath(info *index.VersionInfo) (string, error) {
+	dir := path.Join(knf.GetS(MAIN_TMP_DIR, "/tmp"), ".rbinstall-downloads")
+
+	if !fsutil.IsExist(dir) {
+		err := os.Mkdir(dir, 0700)
+
+		if err != nil {
+			return "", fmt.Errorf("Can't create directory for downloads: %w", err)
+		}
+	}
+
+	// Directory with partially downloaded data must be owned by current user
+	// and not accessible by others
+	uid, _, err := fsutil.GetOwner(dir)
+
+	if err != nil || fsutil.IsLink(dir) || uid != os.Getuid() ||
+		fsutil.GetMode(dir).Perm() != 0700 {
+		return "", fmt.Errorf("Directory %s has unsafe owner or permissions", dir)
+	}
+
+	return path.Join(dir, info.Hash+".part"), nil
+}
+
+// listCache prints info about archives in cache
+func listCache() {
+	if knf.GetS(MAIN_CACHE_DIR) == "" {
+		printWarn("Cache is disabled in configuration file")
+		return
+	}
+
+	archives := getCachedArchives()
+
+	if len(archives) == 0 {
+		if !useRawOutput {
+			printWarn("Cache is empty")
+		}
+
+		return
+	}
+
+	if useRawOutput {
+		for _, archive := range archives {
+			fmt.Println(archive.Name())
+		}
+
+		return
+	}
 
-		for _, gem := range strings.Split(knf.GetS(GEMS_INSTALL), " ") {
-			gemName, gemVersion := parseGemInfo(gem)
+	var totalSize int64
 
-			if isGemInstalled(rubyVersion, gemName) {
-				spinner.Show("Updating %s (%s)", gemName, formatGemVersion(gemVersion))
-				installedVersion, err = updateGemTaskHandler(rubyVersion, gemName, gemVersion)
-			} else {
-				spinner.Show("Installing %s (%s)", gemName, formatGemVersion(gemVersion))
-				installedVersion, err = installGemTaskHandler(rubyVersion, gemName, gemVersion)
-			}
+	fmtutil.Separator(true)
 
-			spinner.Done(err == nil)
+	for _, archive := range archives {
+		hash, name, _ := strings.Cut(archive.Name(), "-")
+		name = strutil.Exclude(name, ".tzst")
 
-			if err == nil {
-				if installedVersion != "" {
-					log.Info(
-						"[%s]Gem %s updated to version %s for %s",
-						currentUser.RealName, gem, installedVersion, rubyVersion,
-					)
-				}
-			} else {
-				terminal.Warn(err.Error())
-			}
-		}
+		fmtc.Printfn(
+			" {*}%-24s{!} {s}|{!} %9s {s}|{!} %s {s}|{!} {s-}%s{!}",
+			name, fmtutil.PrettySize(archive.Size()),
+			timeutil.Format(archive.ModTime(), "%Y/%m/%d %H:%M"),
+			strutil.Head(hash, 12),
+		)
 
-		installed = true
+		totalSize += archive.Size()
 	}
 
-	// //////////////////////////////////////////////////////////////////////////////// //
-
-	if installed {
-		rehashShims()
+	fmtutil.Separator(true)
 
-		fmtc.NewLine()
-		fmtc.Println("{g}All gems successfully updated!{!}")
+	if knf.GetSZ(MAIN_CACHE_SIZE) != 0 {
+		fmtc.Printfn(
+			" {*}Total:{!} %s {s-}/ %s{!}", fmtutil.PrettySize(totalSize),
+			fmtutil.PrettySize(knf.GetSZ(MAIN_CACHE_SIZE)),
+		)
 	} else {
-		fmtc.NewLine()
-		fmtc.Println("{y}There is nothing to update{!}")
+		fmtc.Printfn(" {*}Total:{!} %s", fmtutil.PrettySize(totalSize))
 	}
 }
 
-// runGemCmd run some gem command for some version
-func runGemCmd(rubyVersion, cmd, gem, gemVersion string) (string, error) {
-	start := time.Now()
-	rubyPath := getVersionPath(rubyVersion)
-	gemCmd := exec.Command(rubyPath+"/bin/ruby", rubyPath+"/bin/gem", cmd, gem, "--force")
-
-	if gemVersion != "" {
-		if strings.Count(gemVersion, ".") >= 2 {
-			gemCmd.Args = append(gemCmd.Args, "--version", gemVersion)
-		} else {
-			gemCmd.Args = append(gemCmd.Args, "--version", fmt.Sprintf("~>%s.0", gemVersion))
-		}
+// cleanCache removes all archives from cache
+func cleanCache() {
+	if knf.GetS(MAIN_CACHE_DIR) == "" {
+		printWarn("Cache is disabled in configuration file")
+		return
 	}
 
-	if knf.GetB(GEMS_NO_DOCUMENT) {
-		gemCmd.Args = append(gemCmd.Args, "--no-document")
-	}
+	checkPerms()
 
-	if knf.GetS(GEMS_SOURCE) != "" {
-		gemCmd.Args = append(gemCmd.Args, "--clear-sources", "--source", getGemSourceURL(rubyVersion))
+	archives := getCachedArchives()
+
+	if len(archives) == 0 {
+		printWarn("Cache is empty")
+		return
 	}
 
-	output, err := gemCmd.CombinedOutput()
+	var size int64
 
-	if err == nil {
-		version := getInstalledGemVersion(rubyVersion, gem, start)
+	startTask("Removing cached archives")
 
-		if version == "" {
-			return "", nil
+	for _, archive := range archives {
+		err := os.Remove(path.Join(knf.GetS(MAIN_CACHE_DIR), archive.Name()))
+
+		if err != nil {
+			doneTask(false)
+			printErrorAndExit("Can't remove %s from cache: %v", archive.Name(), err)
 		}
 
-		return version, nil
+		size += archive.Size()
 	}
 
-	actionLog, err := logFailedAction(strings.TrimRight(string(output), "\r\n"))
+	doneTask(true)
 
-	if err == nil {
-		switch cmd {
-		case "update":
-			return "", fmtc.Errorf(
-				"Can't update gem %s (%s). Gem command output saved as %s.",
-				gem, formatGemVersion(gemVersion), actionLog,
-			)
-		default:
-			return "", fmtc.Errorf(
-				"Can't install gem %s (%s). Gem command output saved as %s.",
-				gem, formatGemVersion(gemVersion), actionLog,
-			)
-		}
-	}
+	fmtc.NewLine()
+	fmtc.Printfn(
+		"{g}Cache successfully cleaned {s-}(%s freed){!}",
+		fmtutil.PrettySize(size),
+	)
+}
 
-	switch cmd {
-	case "update":
-		return "", fmtc.Errorf("Can't update gem %s (%s)", gem, formatGemVersion(gemVersion))
-	default:
-		return "", fmtc.Errorf("Can't install gem %s (%s)", gem, formatGemVersion(gemVersion))
+// getCachedArchive returns path to cached archive for given version
+func getCachedArchive(info *index.VersionInfo) string {
+	if knf.GetS(MAIN_CACHE_DIR) == "" {
+		return ""
 	}
-}
 
-// updateRubygems update rubygems to defined version
-func updateRubygems(rubyVersion, gemVersion string) error {
-	rubyPath := getVersionPath(rubyVersion)
-	gemCmd := exec.Command(rubyPath+"/bin/ruby", rubyPath+"/bin/gem", "update", "--no-document", "--system")
+	file := getCacheFilePath(info)
 
-	if gemVersion != "latest" {
-		gemCmd.Args = append(gemCmd.Args, gemVersion)
+	if !fsutil.IsExist(file) {
+		return ""
 	}
 
-	if knf.GetS(GEMS_SOURCE) != "" {
-		gemCmd.Args = append(gemCmd.Args, "--clear-sources", "--source", getGemSourceURL(rubyVersion))
+	if checkHashTaskHandler(file, info.Hash) != nil {
+		os.Remove(file)
+		return ""
 	}
 
-	output, err := gemCmd.CombinedOutput()
+	// Update modification time for LRU eviction
+	now := time.Now()
+	os.Chtimes(file, now, now)
 
-	if err == nil {
+	return file
+}
+
+// cacheArchive saves archive downloaded from given storage to cache
+func cacheArchive(info *index.VersionInfo, file, storageURL string) error {
+	cacheDir := knf.GetS(MAIN_CACHE_DIR)
+
+	// There is no reason to cache archives from local storage
+	if cacheDir == "" || isLocalStorage(storageURL) {
 		return nil
 	}
 
-	actionLog, err := logFailedAction(strings.TrimRight(string(output), "\r\n"))
+	maxSize := knf.GetSZ(MAIN_CACHE_SIZE)
 
-	if err == nil {
-		return fmt.Errorf("Can't update rubygems. Update command output saved as %s", actionLog)
+	if maxSize != 0 && uint64(info.Size) > maxSize {
+		return nil
 	}
 
-	return fmt.Errorf("Can't update rubygems")
-}
+	if !fsutil.IsExist(cacheDir) {
+		err := os.MkdirAll(cacheDir, 0755)
 
-// downloadFile download file from remote host
-func downloadFile(info *index.VersionInfo) (string, error) {
-	tmpDir, err := temp.MkDir()
+		if err != nil {
+			return err
+		}
+	}
+
+	cacheFile := getCacheFilePath(info)
+	err := fsutil.CopyFile(file, cacheFile+".tmp", 0644)
 
 	if err != nil {
-		return "", err
+		os.Remove(cacheFile + ".tmp")
+		return err
 	}
 
-	output := path.Join(tmpDir, info.File)
-	fd, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY, 0644)
+	err = os.Rename(cacheFile+".tmp", cacheFile)
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	defer fd.Close()
+	return evictCachedArchives(maxSize)
+}
 
-	resp, err := req.Request{
-		URL:   knf.GetS(STORAGE_URL) + "/" + info.Path + "/" + info.File,
-		Query: req.Query{"hash": info.Hash},
-	}.Get()
+// evictCachedArchives removes least recently used archives from cache
+// if cache size is bigger than given limit
+func evictCachedArchives(maxSize uint64) error {
+	if maxSize == 0 {
+		return nil
+	}
 
-	if err != nil {
-		return "", err
+	var totalSize uint64
+
+	archives := getCachedArchives()
+
+	for _, archive := range archives {
+		totalSize += uint64(archive.Size())
 	}
 
-	defer resp.Body.Close()
+	for _, archive := range archives {
+		if totalSize <= maxSize {
+			break
+		}
 
-	if resp.StatusCode != 200 {
-		return "", fmtc.Errorf("Server return error code %d", resp.StatusCode)
+		err := os.Remove(path.Join(knf.GetS(MAIN_CACHE_DIR), archive.Name()))
+
+		if err != nil {
+			return err
+		}
+
+		totalSize -= uint64(archive.Size())
 	}
 
-	if noProgress {
-		_, err = io.Copy(fd, resp.Body)
-	} else {
-		pb := progress.New(resp.ContentLength, "")
-		pb.Start()
-		_, err = io.Copy(fd, pb.Reader(resp.Body))
-		pb.Finish()
+	return nil
+}
+
+// getCachedArchives returns info about cached archives sorted by last
+// access time (from oldest to newest)
+func getCachedArchives() []os.FileInfo {
+	var result []os.FileInfo
+
+	cacheDir := knf.GetS(MAIN_CACHE_DIR)
+	files := fsutil.List(
+		cacheDir, true,
+		fsutil.ListingFilter{MatchPatterns: []string{"*.tzst"}},
+	)
+
+	for _, file := range files {
+		fileInfo, err := os.Stat(path.Join(cacheDir, file))
+
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+
+		result = append(result, fileInfo)
 	}
 
-	return output, err
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ModTime().Before(result[j].ModTime())
+	})
+
+	return result
+}
+
+// getCacheFilePath returns path to cached archive for given version
+func getCacheFilePath(info *index.VersionInfo) string {
+	return path.Join(knf.GetS(MAIN_CACHE_DIR), info.Hash+"-"+info.File)
 }
 
 // unpackFile unpacks archived Ruby version
@@ -1439,6 +2435,24 @@ func configureCategorySizes(data map[string]index.CategoryData) {
 	}
 }
 
+// parseInstalledGemVersion returns version of installed gem from gem command
+// output
+func parseInstalledGemVersion(output, gemName string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "Successfully installed ") {
+			continue
+		}
+
+		m := gemDirRegex.FindStringSubmatch(strings.TrimPrefix(line, "Successfully installed "))
+
+		if m != nil && m[1] == gemName {
+			return m[2]
+		}
+	}
+
+	return ""
+}
+
 // installedGemVersion return version of installed gem
 func getInstalledGemVersion(rubyVersion string, gemName string, since time.Time) string {
 	gemsDir := getVersionGemDirPath(rubyVersion)
@@ -1494,6 +2508,8 @@ func isGemInstalled(rubyVersion string, gemName string) bool {
 func formatGemVersion(gemVersion string) string {
 	if gemVersion == "" || gemVersion == "latest" {
 		return "latest"
+	} else if strings.HasPrefix(gemVersion, "=") {
+		return strings.TrimPrefix(gemVersion, "=")
 	} else if strings.Count(gemVersion, ".") < 2 {
 		return fmt.Sprintf("%s.x", gemVersion)
 	}
@@ -1507,32 +2523,41 @@ func isVersionInstalled(rubyVersion string) bool {
 	return fsutil.IsExist(fullPath)
 }
 
-// getVersionFromFile try to read version file and return defined version
-func getVersionFromFile() (string, error) {
-	versionFile := fsutil.ProperPath("FRS", []string{".ruby-version", ".rbenv-version"})
-
-	if versionFile == "" {
-		return "", fmtc.Errorf("Can't find proper version file")
+// getIndexUUID returns UUID of currently used index
+func getIndexUUID() string {
+	if repoIndex == nil {
+		return ""
 	}
 
-	versionData, err := os.ReadFile(versionFile)
+	return repoIndex.UUID
+}
+
+// isVersionOutdated returns true if version was rebuilt after installation
+func isVersionOutdated(info *index.VersionInfo) bool {
+	receipt, err := getInstallReceipt(info.Name)
 
-	if err != nil {
-		return "", fmtc.Errorf("Can't read version file: %v", err)
+	if err == nil {
+		return receipt.Hash != info.Hash || receipt.Added < info.Added
 	}
 
-	versionName := strings.Trim(string(versionData), " \n\r")
+	installDate, err := getInstallDate(info.Name)
 
-	if versionName == "" {
-		return "", fmtc.Errorf("Can't use version file - file malformed")
+	if err != nil {
+		return false
 	}
 
-	return versionName, nil
+	return installDate.Unix() < info.Added
 }
 
 // getAdvisableRubyGemsVersion returns recommended RubyGems version for
 // given version of Ruby
 func getAdvisableRubyGemsVersion(rubyVersion string) string {
+	gs := getGemSet(rubyVersion)
+
+	if gs.RubyGemsVersion != "" {
+		return gs.RubyGemsVersion
+	}
+
 	ver, err := version.Parse(strutil.ReadField(rubyVersion, 0, false, '-'))
 
 	if err != nil {
@@ -1572,6 +2597,76 @@ func getVersionInfo(rubyVersion string) (*index.VersionInfo, string, error) {
 	return info, category, nil
 }
 
+// resolveVersion resolves partial version or version constraint to the name
+// of the newest matching version
+func resolveVersion(constraint string) string {
+	name, err := getResolvedVersionName(constraint)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	if name != constraint {
+		fmtc.Printfn("{s}Version {s*}%s{s} selected for {s*}%s{!}\n", name, constraint)
+	}
+
+	return name
+}
+
+// resolveInstalledVersion returns name of the newest installed version matching
+// given version constraint
+func resolveInstalledVersion(constraint string) string {
+	name := findInstalledVersion(constraint, getInstalledVersionsList())
+
+	if name == "" {
+		printErrorAndExit("Version %s is not installed", constraint)
+	}
+
+	if name != constraint {
+		fmtc.Printfn("{s}Version {s*}%s{s} selected for {s*}%s{!}\n", name, constraint)
+	}
+
+	return name
+}
+
+// getResolvedVersionName returns name of the newest version matching given
+// version constraint
+func getResolvedVersionName(constraint string) (string, error) {
+	osName, archName, err := getSystemInfo()
+
+	if err != nil {
+		return "", err
+	}
+
+	if info, _ := repoIndex.Find(osName, archName, constraint); info != nil {
+		return constraint, nil
+	}
+
+	allowEOL := options.GetB(OPT_ALLOW_EOL)
+	info, _, err := repoIndex.Resolve(osName, archName, constraint, allowEOL)
+
+	if err != nil {
+		return "", err
+	}
+
+	if info != nil {
+		return info.Name, nil
+	}
+
+	if !allowEOL {
+		info, _, _ = repoIndex.Resolve(osName, archName, constraint, true)
+
+		if info != nil {
+			return "", fmt.Errorf(
+				"Only EOL versions match %q (use %s to allow them)",
+				constraint, options.Format(OPT_ALLOW_EOL),
+			)
+		}
+	}
+
+	return "", fmt.Errorf("Can't find version matching %q for your OS", constraint)
+}
+
 // getInstalledVersionsMap returns map with names of installed versions
 func getInstalledVersionsMap() map[string]bool {
 	result := make(map[string]bool)
@@ -1591,6 +2686,22 @@ func getInstalledVersionsMap() map[string]bool {
 	return result
 }
 
+// getInstalledVersionsList returns sorted slice with names of installed
+// versions (without aliases)
+func getInstalledVersionsList() []string {
+	var result []string
+
+	for versionName := range getInstalledVersionsMap() {
+		if !fsutil.IsLink(getVersionPath(versionName)) {
+			result = append(result, versionName)
+		}
+	}
+
+	sortutil.Versions(result)
+
+	return result
+}
+
 // filterCategoryData filters category data removing EOL versions
 func filterCategoryData(versions index.CategoryData, installed map[string]bool) index.CategoryData {
 	var result index.CategoryData
@@ -1661,6 +2772,43 @@ func getRBEnvVersionsPath() string {
 	return path.Join(knf.GetS(RBENV_DIR), "versions")
 }
 
+// getStorageURLs returns URLs of all storages ordered by priority
+func getStorageURLs() []string {
+	result := []string{knf.GetS(STORAGE_URL)}
+
+	for _, mirrorURL := range knf.GetL(STORAGE_MIRRORS) {
+		if !slices.Contains(result, mirrorURL) {
+			result = append(result, mirrorURL)
+		}
+	}
+
+	return result
+}
+
+// getDownloadStorageURLs returns URLs of storages for downloading archives
+// starting with the storage which served index
+func getDownloadStorageURLs() []string {
+	result := []string{repoStorageURL}
+
+	for _, storageURL := range getStorageURLs() {
+		if storageURL != repoStorageURL {
+			result = append(result, storageURL)
+		}
+	}
+
+	return result
+}
+
+// isLocalStorage returns true if storage is a local directory
+func isLocalStorage(storageURL string) bool {
+	return strings.HasPrefix(storageURL, "file://")
+}
+
+// getLocalStoragePath returns path to local storage directory
+func getLocalStoragePath(storageURL string) string {
+	return strutil.Exclude(storageURL, "file://")
+}
+
 // getUnpackDirPath return path to directory for unpacking data
 func getUnpackDirPath() string {
 	return path.Join(getRBEnvVersionsPath(), ".rbinstall")
@@ -1706,17 +2854,30 @@ func checkRBEnv() {
 	}
 }
 
-// checkDependencies check dependencies for given category
-func checkDependencies(info *index.VersionInfo, category string) {
+// checkIndexSignature checks result of repository index signature verification
+func checkIndexSignature() {
+	if repoIndexSigErr == nil || knf.GetB(STORAGE_ALLOW_UNSIGNED, false) {
+		return
+	}
+
+	printErrorAndExit(
+		"Can't verify repository index signature: %v. Check storage:keys in %s (or set storage:allow-unsigned to true if repository is not signed).",
+		repoIndexSigErr, CONFIG_FILE,
+	)
+}
+
+// validateDependencies returns error if some dependencies for given version
+// are not installed
+func validateDependencies(info *index.VersionInfo, category string) error {
 	if category == index.CATEGORY_JRUBY && env.Which("java") == "" {
-		printErrorAndExit("Java is required for this variation of Ruby")
+		return fmt.Errorf("Java is required for this variation of Ruby")
 	}
 
-	if strings.HasSuffix(info.Name, "jemalloc") {
-		if !isLibLoaded("libjemalloc.so.2") {
-			printErrorAndExit("Jemalloc 5+ is required for this version of Ruby")
-		}
+	if strings.HasSuffix(info.Name, "jemalloc") && !isLibLoaded("libjemalloc.so.2") {
+		return fmt.Errorf("Jemalloc 5+ is required for this version of Ruby")
 	}
+
+	return nil
 }
 
 // getSystemInfo return info about system
@@ -1853,22 +3014,38 @@ func logFailedAction(message string) (string, error) {
 
 // intSignalHandler is INT (Ctrl+C) signal handler
 func intSignalHandler() {
-	spinner.Done(false)
+	// Install transaction is rolled back by main goroutine, so here we
+	// only mark process as canceled
+	if cancelInstall() {
+		terminal.Warn("\n\nCanceling install process…")
+		return
+	}
+
+	doneTask(false)
 	printErrorAndExit("\n\nInstall process canceled by Ctrl+C")
 }
 
 // printErrorAndExit print error message and exit with non-zero exit code
 func printErrorAndExit(f string, a ...any) {
+	saveActionError(f, a...)
 	terminal.Error(f, a...)
 	exit(1)
 }
 
 // exit exits clean temporary data and exit from utility with given exit code
 func exit(code int) {
+	if code != 0 {
+		rollbackInstall()
+	}
+
+	releaseLock()
+
 	if temp != nil {
 		temp.Clean()
 	}
 
+	printActionResult(code)
+
 	fmtc.NewLine()
 	os.Exit(code)
 }
@@ -1937,11 +3114,26 @@ func genUsage() *usage.Info {
 	info.AddOption(OPT_REINSTALL_UPDATED, "Reinstall all updated (rebuilt) versions")
 	info.AddOption(OPT_GEMS_UPDATE, "Update gems for some version")
 	info.AddOption(OPT_REHASH, "Rehash rbenv shims")
+	info.AddOption(OPT_CACHE_LIST, "List archives in cache")
+	info.AddOption(OPT_CACHE_CLEAN, "Remove all archives from cache")
 	info.AddOption(OPT_GEMS_INSECURE, "Use HTTP instead of HTTPS for installing gems")
 	info.AddOption(OPT_RUBY_VERSION, "Install version defined in version file")
+	info.AddOption(OPT_FROM_FILE, "Install version from local archive", "file")
+	info.AddOption(OPT_SYNC, "Install and uninstall versions to match manifest", "manifest")
+	info.AddOption(OPT_ALLOW_EOL, "Allow EOL versions when resolving version constraint")
+	info.AddOption(OPT_DRY_RUN, "Show what would be done without making any changes")
+	info.AddOption(OPT_INDEX, "Use local index file instead of index from storage", "file")
 	info.AddOption(OPT_INFO, "Print detailed info about version")
+	info.AddOption(OPT_VERIFY, "Verify installed files against file manifest")
+	info.AddOption(OPT_PRUNE, "Uninstall unused EOL and superseded versions")
+	info.AddOption(OPT_UPGRADE, "Upgrade version to the latest release in the same minor line")
+	info.AddOption(OPT_UPDATE_REFS, "Update global version and version files while upgrading")
+	info.AddOption(OPT_REMOVE_OLD, "Uninstall old version after upgrade if it is not used anymore")
+	info.AddOption(OPT_MIGRATE_GEMS, "Install gems from one version to another")
+	info.AddOption(OPT_YES, `Answer "yes" to all questions`)
 	info.AddOption(OPT_ALL, "Print all available versions")
 	info.AddOption(OPT_PAGER, "Use pager for long output")
+	info.AddOption(OPT_FORMAT, "Output format {s-}(json/yaml){!}", "format")
 	info.AddOption(OPT_NO_PROGRESS, "Disable progress bar and spinner")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
@@ -1949,11 +3141,21 @@ func genUsage() *usage.Info {
 
 	info.AddExample("2.0.0-p598", "Install 2.0.0-p598")
 	info.AddExample("2.0.0", "Install latest available release in 2.0.0")
+	info.AddExample("3.3", "Install the newest available release of 3.3")
+	info.AddExample("'~> 3.2' -E", "Install the newest 3.x release including EOL versions")
 	info.AddExample("2.0.0 -i", "Show details and available variations for 2.0.0")
 	info.AddExample("2.0.0-p598-railsexpress", "Install 2.0.0-p598 with railsexpress patches")
 	info.AddExample("2.0.0-p598 -G", "Update gems installed for 2.0.0-p598")
 	info.AddExample("2.0.0-p598 --reinstall", "Reinstall 2.0.0-p598")
 	info.AddExample("-r", "Install version defined in .ruby-version file")
+	info.AddExample("3.3.6 --dry-run", "Show what installing 3.3.6 would do")
+	info.AddExample("-a -f json", "Print all available versions in JSON format")
+	info.AddExample("--sync rubies.knf", "Install and uninstall versions to match manifest")
+	info.AddExample("--upgrade 3.3.5 --update-refs --remove-old", "Upgrade 3.3.5 to the latest 3.3.x release and uninstall 3.3.5")
+	info.AddExample("--migrate-gems 3.2.6 3.3.6", "Install all gems installed for 3.2.6 to 3.3.6")
+	info.AddExample("--prune --dry-run", "Show which versions would be pruned")
+	info.AddExample("--verify", "Verify files of all installed versions {s-}(exit codes: 0 OK, 1 extra files, 2 modified or missing files, 3 unknown){!}")
+	info.AddExample("-F 3.3.6.tzst -I index3.json", "Install 3.3.6 from local archive verified with local index")
 
 	return info
 }
@@ -0,0 +1,349 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/sortutil"
+	"github.com/essentialkaos/ek/v13/strutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dryRunStep contains info about changes which would be made by action
+type dryRunStep struct {
+	Action       string   `json:"action"`
+	Version      string   `json:"version"`
+	Category     string   `json:"category,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	DownloadSize int64    `json:"download_size"`
+	Cached       bool     `json:"cached"`
+	RubyGems     string   `json:"rubygems,omitempty"`
+	Gems         []string `json:"gems,omitempty"`
+	Create       []string `json:"create,omitempty"`
+	Remove       []string `json:"remove,omitempty"`
+	Problems     []string `json:"problems,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dryRunVersionAction shows what action for given version would do
+func dryRunVersionAction(rubyVersion string) {
+	var step *dryRunStep
+
+	switch {
+	case options.GetB(OPT_GEMS_UPDATE):
+		startAction("gems-update", rubyVersion)
+		step = getGemsUpdateDryRunStep(rubyVersion)
+	case options.GetB(OPT_REINSTALL):
+		startAction("reinstall", rubyVersion)
+		step = getInstallDryRunStep(rubyVersion, true)
+	case options.GetB(OPT_UNINSTALL):
+		startAction("uninstall", rubyVersion)
+		step = getUninstallDryRunStep(rubyVersion)
+	default:
+		startAction("install", rubyVersion)
+		step = getInstallDryRunStep(rubyVersion, false)
+	}
+
+	printDryRunSteps([]*dryRunStep{step})
+}
+
+// dryRunInstallFromFile shows what installing from local archive would do
+func dryRunInstallFromFile(file string) {
+	reinstall := options.GetB(OPT_REINSTALL)
+	step := getInstallDryRunStep(strutil.Exclude(path.Base(file), ".tzst"), reinstall)
+
+	step.Source, step.DownloadSize, step.Cached = file, 0, false
+
+	if step.Category != "" {
+		info, _, _ := getVersionInfo(step.Version)
+		step.Create = slices.DeleteFunc(step.Create, func(p string) bool {
+			return p == getCacheFilePath(info)
+		})
+	}
+
+	if !fsutil.CheckPerms("FRS", file) {
+		step.Problems = append(step.Problems, fmt.Sprintf("File %s doesn't exist, empty or not readable", file))
+	}
+
+	printDryRunSteps([]*dryRunStep{step})
+}
+
+// dryRunReinstallUpdated shows what reinstalling of rebuilt versions would do
+func dryRunReinstallUpdated() {
+	var steps []*dryRunStep
+	var versions []string
+
+	for rubyVersion := range getInstalledVersionsMap() {
+		versions = append(versions, rubyVersion)
+	}
+
+	sortutil.Versions(versions)
+
+	for _, rubyVersion := range versions {
+		info, _, err := getVersionInfo(rubyVersion)
+
+		if err != nil || !isVersionOutdated(info) {
+			continue
+		}
+
+		steps = append(steps, getInstallDryRunStep(rubyVersion, true))
+	}
+
+	if len(steps) == 0 {
+		fmtc.Println("{g}All versions are up-to-date{!}")
+		return
+	}
+
+	printDryRunSteps(steps)
+}
+
+// getInstallDryRunStep returns info about changes which would be made by
+// installing given version
+func getInstallDryRunStep(rubyVersion string, reinstall bool) *dryRunStep {
+	step := &dryRunStep{Action: "install", Version: rubyVersion}
+
+	if reinstall {
+		step.Action = "reinstall"
+	}
+
+	info, category, err := getVersionInfo(rubyVersion)
+
+	if err != nil {
+		step.Problems = append(step.Problems, err.Error())
+		return step
+	}
+
+	installed := isVersionInstalled(info.Name)
+
+	step.Version, step.Category = info.Name, category
+	step.Source = fmt.Sprintf("%s/%s/%s", repoStorageURL, info.Path, info.File)
+
+	switch {
+	case installed && !reinstall:
+		step.Problems = append(step.Problems, fmt.Sprintf("Version %s already installed", info.Name))
+	case !installed && reinstall:
+		step.Problems = append(step.Problems, fmt.Sprintf("Version %s is not installed", info.Name))
+	case reinstall && !knf.GetB(RBENV_ALLOW_OVERWRITE, false):
+		step.Problems = append(step.Problems, "Reinstalling is not allowed")
+	}
+
+	if repoIndexSigErr != nil && !knf.GetB(STORAGE_ALLOW_UNSIGNED, false) {
+		step.Problems = append(step.Problems, fmt.Sprintf("Can't verify repository index signature: %v", repoIndexSigErr))
+	}
+
+	step.Problems = append(step.Problems, getRBEnvProblems()...)
+
+	err = validateDependencies(info, category)
+
+	if err != nil {
+		step.Problems = append(step.Problems, err.Error())
+	}
+
+	if knf.GetS(MAIN_CACHE_DIR) != "" && fsutil.IsExist(getCacheFilePath(info)) {
+		step.Cached = true
+	} else {
+		step.DownloadSize = info.Size
+	}
+
+	if knf.GetS(MAIN_CACHE_DIR) != "" && !step.Cached && !isLocalStorage(repoStorageURL) {
+		step.Create = append(step.Create, getCacheFilePath(info))
+	}
+
+	gs := getGemSet(info.Name)
+
+	if gs.RubyGemsUpdate && strutil.HasPrefixAny(info.Name, "1", "2", "3") {
+		step.RubyGems = formatGemVersion(getAdvisableRubyGemsVersion(info.Name))
+	}
+
+	for _, gem := range gs.Install {
+		gemName, gemVersion := parseGemInfo(gem)
+
+		if gemName == "bundler" && gemVersion == "" && !isVersionSupportedByBundler(info.Name) {
+			continue
+		}
+
+		step.Gems = append(step.Gems, fmt.Sprintf("install %s (%s)", gemName, formatGemVersion(gemVersion)))
+	}
+
+	if installed {
+		step.Remove = append(step.Remove, getVersionPath(info.Name))
+	}
+
+	step.Create = append(step.Create, getVersionPath(info.Name), getRegistryRecordPath(info.Name))
+
+	if strings.Contains(info.Name, "-p0") && knf.GetB(RBENV_MAKE_ALIAS, false) {
+		aliasPath := getVersionPath(getNameWithoutPatchLevel(info.Name))
+
+		if !fsutil.IsExist(aliasPath) {
+			step.Create = append(step.Create, aliasPath)
+		}
+	}
+
+	return step
+}
+
+// getUninstallDryRunStep returns info about changes which would be made by
+// uninstalling given version
+func getUninstallDryRunStep(rubyVersion string) *dryRunStep {
+	step := &dryRunStep{Action: "uninstall", Version: rubyVersion}
+
+	if !knf.GetB(RBENV_ALLOW_UNINSTALL, false) {
+		step.Problems = append(step.Problems, "Uninstalling is not allowed")
+	}
+
+	versionName, err := getInstalledVersionName(rubyVersion)
+
+	if err != nil {
+		step.Problems = append(step.Problems, err.Error())
+		return step
+	}
+
+	step.Version = versionName
+	_, step.Category, _ = getVersionInfo(versionName)
+	step.Remove = append(step.Remove, getVersionPath(versionName))
+
+	aliasPath := getVersionPath(getNameWithoutPatchLevel(versionName))
+
+	if aliasPath != getVersionPath(versionName) && fsutil.IsExist(aliasPath) {
+		step.Remove = append(step.Remove, aliasPath)
+	}
+
+	if isVersionRegistered(versionName) {
+		step.Remove = append(step.Remove, getRegistryRecordPath(versionName))
+	}
+
+	return step
+}
+
+// getGemsUpdateDryRunStep returns info about changes which would be made by
+// updating gems for given version
+func getGemsUpdateDryRunStep(rubyVersion string) *dryRunStep {
+	step := &dryRunStep{Action: "gems-update", Version: rubyVersion}
+
+	if !knf.GetB(GEMS_ALLOW_UPDATE, true) {
+		step.Problems = append(step.Problems, "Gems update is disabled in configuration file")
+	}
+
+	if !isVersionInstalled(rubyVersion) {
+		step.Problems = append(step.Problems, fmt.Sprintf("Version %s is not installed", rubyVersion))
+		return step
+	}
+
+	_, step.Category, _ = getVersionInfo(rubyVersion)
+	step.Problems = append(step.Problems, getRBEnvProblems()...)
+
+	gs := getGemSet(rubyVersion)
+
+	if gs.RubyGemsUpdate {
+		step.RubyGems = formatGemVersion(getAdvisableRubyGemsVersion(rubyVersion))
+	}
+
+	for _, gem := range gs.Install {
+		gemName, gemVersion := parseGemInfo(gem)
+
+		if isGemInstalled(rubyVersion, gemName) {
+			step.Gems = append(step.Gems, fmt.Sprintf("update %s (%s)", gemName, formatGemVersion(gemVersion)))
+		} else {
+			step.Gems = append(step.Gems, fmt.Sprintf("install %s (%s)", gemName, formatGemVersion(gemVersion)))
+		}
+	}
+
+	return step
+}
+
+// getRBEnvProblems returns problems with rbenv installation
+func getRBEnvProblems() []string {
+	var result []string
+
+	if !fsutil.IsDir(getRBEnvVersionsPath()) {
+		result = append(result, fmt.Sprintf("Directory %s doesn't exist", getRBEnvVersionsPath()))
+	}
+
+	if !fsutil.CheckPerms("FX", knf.GetS(RBENV_DIR)+"/libexec/rbenv") {
+		result = append(result, "rbenv is not installed")
+	}
+
+	return result
+}
+
+// printDryRunSteps prints info about changes which would be made by action
+func printDryRunSteps(steps []*dryRunStep) {
+	var hasProblems bool
+
+	for _, step := range steps {
+		printDryRunStep(step)
+		hasProblems = hasProblems || len(step.Problems) != 0
+	}
+
+	if rootResult != nil {
+		rootResult.DryRun = steps
+	}
+
+	fmtc.NewLine()
+
+	if hasProblems {
+		printErrorAndExit("Action can't be completed due to problems listed above")
+	}
+
+	fmtc.Println("{g}Dry run finished, no changes were made{!}")
+}
+
+// printDryRunStep prints info about dry run step
+func printDryRunStep(step *dryRunStep) {
+	fmtutil.Separator(true)
+
+	fmtc.Printfn(" {*}%-12s{!} {s}|{!} %s", "Action", step.Action)
+	fmtc.Printfn(" {*}%-12s{!} {s}|{!} %s", "Version", step.Version)
+
+	if step.Source != "" {
+		switch {
+		case step.Cached:
+			fmtc.Printfn(" {*}%-12s{!} {s}|{!} %s {s-}(cached){!}", "Source", step.Source)
+		case step.DownloadSize != 0:
+			fmtc.Printfn(
+				" {*}%-12s{!} {s}|{!} %s {s-}(%s){!}", "Source",
+				step.Source, fmtutil.PrettySize(step.DownloadSize),
+			)
+		default:
+			fmtc.Printfn(" {*}%-12s{!} {s}|{!} %s", "Source", step.Source)
+		}
+	}
+
+	if step.RubyGems != "" {
+		fmtc.Printfn(" {*}%-12s{!} {s}|{!} %s", "RubyGems", step.RubyGems)
+	}
+
+	printDryRunList("Gems", step.Gems, "")
+	printDryRunList("Create", step.Create, "{g}")
+	printDryRunList("Remove", step.Remove, "{y}")
+	printDryRunList("Problems", step.Problems, "{r}")
+
+	fmtutil.Separator(true)
+}
+
+// printDryRunList prints list of values in dry run info
+func printDryRunList(name string, values []string, colorTag string) {
+	for index, value := range values {
+		if index != 0 {
+			name = ""
+		}
+
+		fmtc.Printfn(" {*}%-12s{!} {s}|{!} "+colorTag+"%s{!}", name, value)
+	}
+}
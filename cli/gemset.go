@@ -0,0 +1,110 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/knf"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GEMSET_SECTION_PREFIX is prefix of config sections with gem sets for
+// particular versions (e.g. [gems-3.3*])
+const GEMSET_SECTION_PREFIX = "gems-"
+
+// GEMSET_NONE is value used for disabling gems install in gem set
+const GEMSET_NONE = "none"
+
+// Gem set properties
+const (
+	GEMSET_INSTALL          = "install"
+	GEMSET_RUBYGEMS_UPDATE  = "rubygems-update"
+	GEMSET_RUBYGEMS_VERSION = "rubygems-version"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// gemSet contains gems configuration for particular version
+type gemSet struct {
+	Install         []string // List of gems to install
+	RubyGemsUpdate  bool     // Update RubyGems
+	RubyGemsVersion string   // RubyGems version defined in gem set
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getGemSet returns gems configuration for given version. Properties from
+// sections with more specific version patterns override properties from
+// sections with more general patterns and from main gems section.
+func getGemSet(rubyVersion string) *gemSet {
+	gs := &gemSet{
+		Install:        knf.GetL(GEMS_INSTALL),
+		RubyGemsUpdate: knf.GetB(GEMS_RUBYGEMS_UPDATE),
+	}
+
+	for _, section := range getGemSetSections(rubyVersion) {
+		if knf.Has(knf.Q(section, GEMSET_INSTALL)) {
+			gs.Install = knf.GetL(knf.Q(section, GEMSET_INSTALL))
+
+			if len(gs.Install) == 1 && gs.Install[0] == GEMSET_NONE {
+				gs.Install = nil
+			}
+		}
+
+		if knf.Has(knf.Q(section, GEMSET_RUBYGEMS_UPDATE)) {
+			gs.RubyGemsUpdate = knf.GetB(knf.Q(section, GEMSET_RUBYGEMS_UPDATE))
+		}
+
+		if knf.Has(knf.Q(section, GEMSET_RUBYGEMS_VERSION)) {
+			gs.RubyGemsVersion = knf.GetS(knf.Q(section, GEMSET_RUBYGEMS_VERSION))
+		}
+	}
+
+	return gs
+}
+
+// getGemSetSections returns names of sections with gem sets matching given
+// version ordered from the most general to the most specific
+func getGemSetSections(rubyVersion string) []string {
+	var result []string
+
+	for _, section := range knf.Sections() {
+		pattern, ok := strings.CutPrefix(section, GEMSET_SECTION_PREFIX)
+
+		if !ok {
+			continue
+		}
+
+		match, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(rubyVersion))
+
+		if match {
+			result = append(result, section)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return getPatternSpecificity(result[i]) < getPatternSpecificity(result[j])
+	})
+
+	return result
+}
+
+// getPatternSpecificity returns specificity of version pattern (number of
+// characters which are not wildcards)
+func getPatternSpecificity(pattern string) int {
+	if !strings.ContainsAny(pattern, "*?[") {
+		// Exact version name is more specific than any pattern
+		return len(pattern) * 2
+	}
+
+	return len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+}
@@ -0,0 +1,59 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"slices"
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestGetGemSet(t *testing.T) {
+	setupTestRBEnv(t, `
+[gems]
+  install: rake bundler
+  rubygems-update: true
+
+[gems-JRuby-*]
+  install: jruby-openssl
+
+[gems-3.3*]
+  install: none
+
+[gems-3.3.6]
+  rubygems-update: false
+  rubygems-version: 3.5.0
+`)
+
+	tests := []struct {
+		Version         string
+		Install         []string
+		RubyGemsUpdate  bool
+		RubyGemsVersion string
+	}{
+		{"3.2.1", []string{"rake", "bundler"}, true, ""},
+		{"jruby-9.4.9.0", []string{"jruby-openssl"}, true, ""},
+		{"JRuby-9.4.9.0", []string{"jruby-openssl"}, true, ""},
+		{"3.3.0", nil, true, ""},
+		{"3.3.6", nil, false, "3.5.0"},
+	}
+
+	for _, tt := range tests {
+		gs := getGemSet(tt.Version)
+
+		switch {
+		case !slices.Equal(gs.Install, tt.Install):
+			t.Errorf("[%s] Gem set contains gems %v, want %v", tt.Version, gs.Install, tt.Install)
+		case gs.RubyGemsUpdate != tt.RubyGemsUpdate:
+			t.Errorf("[%s] RubyGems update flag is %t, want %t", tt.Version, gs.RubyGemsUpdate, tt.RubyGemsUpdate)
+		case gs.RubyGemsVersion != tt.RubyGemsVersion:
+			t.Errorf("[%s] RubyGems version is %q, want %q", tt.Version, gs.RubyGemsVersion, tt.RubyGemsVersion)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Gem task statuses
+const (
+	GEM_TASK_PENDING uint8 = iota
+	GEM_TASK_RUNNING
+	GEM_TASK_DONE
+	GEM_TASK_FAILED
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// gemTask contains info about gem install task
+type gemTask struct {
+	Name    string
+	Version string
+	Status  uint8
+	Err     error
+	Started time.Time
+}
+
+// gemGroup contains tasks which must be installed sequentially because they
+// share some gems
+type gemGroup struct {
+	Tasks []*gemTask
+	Gems  map[string]bool // Names of all gems installed by tasks
+}
+
+// gemsProgress is multi-line progress view for parallel gems install
+type gemsProgress struct {
+	tasks []*gemTask
+	frame int
+	drawn bool
+	stop  chan bool
+	done  chan bool
+	mx    sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// gemsProgressFrames contains spinner animation frames
+var gemsProgressFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// installGemsParallel installs gems for given version using pool of workers.
+// Gems which share dependencies are installed sequentially by the same worker,
+// so only disjoint sets of gems are written to gems directory concurrently.
+func installGemsParallel(rubyVersion string, gems []string, workers int) []error {
+	var tasks []*gemTask
+
+	for _, gem := range gems {
+		gemName, gemVersion := parseGemInfo(gem)
+		tasks = append(tasks, &gemTask{Name: gemName, Version: gemVersion})
+	}
+
+	startTask("Resolving gems dependencies")
+	groups, err := groupGemTasks(rubyVersion, tasks, workers)
+	doneTask(err == nil)
+
+	if err != nil {
+		printWarn("%v. Gems will be installed sequentially.", err)
+	}
+
+	queue := make(chan []*gemTask, len(groups))
+
+	for _, group := range groups {
+		queue <- group
+	}
+
+	close(queue)
+
+	pv := newGemsProgress(tasks)
+	wg := &sync.WaitGroup{}
+
+	for range min(workers, len(groups)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for group := range queue {
+				for _, task := range group {
+					// Remaining tasks stay pending and other groups are not
+					// taken from queue after cancellation
+					if checkCanceled() != nil {
+						return
+					}
+
+					pv.Update(task, GEM_TASK_RUNNING, nil)
+					_, err := installGemTaskHandler(rubyVersion, task.Name, task.Version)
+
+					if err != nil {
+						pv.Update(task, GEM_TASK_FAILED, err)
+					} else {
+						pv.Update(task, GEM_TASK_DONE, nil)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	pv.Finish()
+
+	var errs []error
+
+	if checkCanceled() != nil {
+		errs = append(errs, errCanceled)
+	}
+
+	for _, task := range tasks {
+		if task.Status == GEM_TASK_PENDING {
+			continue
+		}
+
+		addTaskResult(
+			fmt.Sprintf("Installing %s (%s)", task.Name, formatGemVersion(task.Version)),
+			task.Status == GEM_TASK_DONE, task.Started,
+		)
+
+		if task.Err != nil {
+			errs = append(errs, task.Err)
+		}
+	}
+
+	return errs
+}
+
+// groupGemTasks splits tasks into groups of tasks which share some gems
+func groupGemTasks(rubyVersion string, tasks []*gemTask, workers int) ([][]*gemTask, error) {
+	deps := make([][]string, len(tasks))
+	errs := make([]error, len(tasks))
+	sem := make(chan bool, workers)
+	wg := &sync.WaitGroup{}
+
+	for i, task := range tasks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- true
+			deps[i], errs[i] = getGemDependencies(rubyVersion, task.Name, task.Version)
+			<-sem
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return [][]*gemTask{tasks}, err
+		}
+	}
+
+	var groups []*gemGroup
+
+	for i, task := range tasks {
+		group := &gemGroup{
+			Tasks: []*gemTask{task},
+			Gems:  map[string]bool{task.Name: true},
+		}
+
+		for _, gem := range deps[i] {
+			group.Gems[gem] = true
+		}
+
+		var disjoint []*gemGroup
+
+		for _, g := range groups {
+			if g.IsIntersect(group) {
+				group.Merge(g)
+			} else {
+				disjoint = append(disjoint, g)
+			}
+		}
+
+		groups = append(disjoint, group)
+	}
+
+	var result [][]*gemTask
+
+	// Gems must be installed in the same order as defined in configuration
+	for _, g := range groups {
+		slices.SortFunc(g.Tasks, func(a, b *gemTask) int {
+			return slices.Index(tasks, a) - slices.Index(tasks, b)
+		})
+
+		result = append(result, g.Tasks)
+	}
+
+	slices.SortFunc(result, func(a, b []*gemTask) int {
+		return slices.Index(tasks, a[0]) - slices.Index(tasks, b[0])
+	})
+
+	return result, nil
+}
+
+// getGemDependencies returns names of all gems which will be installed with
+// given gem
+func getGemDependencies(rubyVersion, gem, gemVersion string) ([]string, error) {
+	var result []string
+
+	gemCmd := getGemCmd(rubyVersion, "install", gem, gemVersion)
+	gemCmd.Args = append(gemCmd.Args, "--explain")
+
+	output, err := gemCmd.Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't resolve dependencies of gem %s (%s)", gem, formatGemVersion(gemVersion))
+	}
+
+	// Output contains full names of gems (e.g. "  nokogiri-1.15.4-x86_64-linux")
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.HasPrefix(line, "  ") {
+			continue
+		}
+
+		m := gemDirRegex.FindStringSubmatch(strings.TrimSpace(line))
+
+		if m != nil {
+			result = append(result, m[1])
+		}
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsIntersect returns true if groups share some gems
+func (g *gemGroup) IsIntersect(other *gemGroup) bool {
+	for gem := range other.Gems {
+		if g.Gems[gem] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Merge adds tasks and gems from given group
+func (g *gemGroup) Merge(other *gemGroup) {
+	g.Tasks = append(g.Tasks, other.Tasks...)
+
+	for gem := range other.Gems {
+		g.Gems[gem] = true
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newGemsProgress creates and starts new progress view
+func newGemsProgress(tasks []*gemTask) *gemsProgress {
+	pv := &gemsProgress{tasks: tasks}
+
+	if noProgress || useRawOutput {
+		return pv
+	}
+
+	pv.stop, pv.done = make(chan bool), make(chan bool)
+
+	go pv.renderLoop()
+
+	return pv
+}
+
+// Update updates status of given task
+func (pv *gemsProgress) Update(task *gemTask, status uint8, err error) {
+	pv.mx.Lock()
+	defer pv.mx.Unlock()
+
+	task.Status, task.Err = status, err
+
+	if status == GEM_TASK_RUNNING {
+		task.Started = time.Now()
+	}
+
+	// Without animation only final statuses are printed
+	if pv.stop == nil && status != GEM_TASK_RUNNING {
+		fmtc.Println(pv.formatTask(task))
+	}
+}
+
+// Finish stops rendering and prints final state of all tasks
+func (pv *gemsProgress) Finish() {
+	if pv.stop == nil {
+		return
+	}
+
+	pv.stop <- true
+	<-pv.done
+}
+
+// renderLoop redraws progress view until it stopped
+func (pv *gemsProgress) renderLoop() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pv.stop:
+			pv.render()
+			pv.done <- true
+			return
+		case <-ticker.C:
+			pv.render()
+		}
+	}
+}
+
+// render draws all tasks statuses
+func (pv *gemsProgress) render() {
+	pv.mx.Lock()
+	defer pv.mx.Unlock()
+
+	var buf strings.Builder
+
+	if pv.drawn {
+		// Move cursor to the first line of view
+		fmt.Fprintf(&buf, "\033[%dA", len(pv.tasks))
+	}
+
+	for _, task := range pv.tasks {
+		buf.WriteString("\033[2K" + pv.formatTask(task) + "\n")
+	}
+
+	fmtc.Print(buf.String())
+
+	pv.drawn = true
+	pv.frame = (pv.frame + 1) % len(gemsProgressFrames)
+}
+
+// formatTask formats task status line
+func (pv *gemsProgress) formatTask(task *gemTask) string {
+	name := fmt.Sprintf("Installing %s (%s)", task.Name, formatGemVersion(task.Version))
+
+	switch task.Status {
+	case GEM_TASK_RUNNING:
+		return "{y}" + gemsProgressFrames[pv.frame] + "  {!}" + name
+	case GEM_TASK_DONE:
+		return "{g}✔  {!}" + name
+	case GEM_TASK_FAILED:
+		return "{r}✖  {!}" + name
+	}
+
+	return "{s-}•  " + name + "{!}"
+}
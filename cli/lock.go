@@ -0,0 +1,146 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/pid"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// LOCK_FILE is name of install lock file in rbenv directory
+const LOCK_FILE = ".rbinstall.lock"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// lockFd is descriptor of locked install lock file
+var lockFd *os.File
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// acquireLock acquires install lock preventing concurrent modification of
+// rbenv directory
+func acquireLock() {
+	if lockFd != nil || !fsutil.IsDir(knf.GetS(RBENV_DIR)) {
+		return
+	}
+
+	lockFile := getLockFilePath()
+	fd, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR|syscall.O_NOFOLLOW, 0644)
+
+	if err != nil {
+		printErrorAndExit("Can't open lock file: %v", err)
+	}
+
+	err = waitForLock(fd, lockFile, knf.GetTD(RBENV_LOCK_TIMEOUT, 5*time.Minute))
+
+	if err != nil {
+		fd.Close()
+		printErrorAndExit("%v", err)
+	}
+
+	// Lock file is truncated on release, so PID in it means that
+	// previous process was killed while holding the lock
+	stalePID := pid.Read(lockFile)
+
+	if stalePID > 0 && stalePID != os.Getpid() {
+		log.Warn("Found stale install lock left by process %d", stalePID)
+		printWarn("Found stale install lock left by process %d, lock reacquired", stalePID)
+	}
+
+	fd.Truncate(0)
+	fd.WriteAt(fmt.Appendf(nil, "%d\n", os.Getpid()), 0)
+
+	lockFd = fd
+}
+
+// waitForLock waits until exclusive lock on given lock file is acquired or
+// timeout is reached
+func waitForLock(fd *os.File, lockFile string, timeout time.Duration) error {
+	var isWaiting bool
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := syscall.Flock(int(fd.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+		if err == nil {
+			break
+		}
+
+		if err != syscall.EWOULDBLOCK {
+			return fmt.Errorf("Can't lock %s: %w", lockFile, err)
+		}
+
+		holder := getLockHolder(lockFile)
+
+		if time.Now().After(deadline) {
+			if isWaiting {
+				doneTask(false)
+				fmtc.NewLine()
+			}
+
+			return fmt.Errorf(
+				"Can't acquire install lock: rbenv directory is locked by another rbinstall process %s",
+				holder,
+			)
+		}
+
+		if !isWaiting {
+			startTask("Waiting for another rbinstall process %s to finish", holder)
+			isWaiting = true
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	if isWaiting {
+		doneTask(true)
+	}
+
+	return nil
+}
+
+// releaseLock releases install lock
+func releaseLock() {
+	if lockFd == nil {
+		return
+	}
+
+	lockFd.Truncate(0)
+	syscall.Flock(int(lockFd.Fd()), syscall.LOCK_UN)
+	lockFd.Close()
+
+	lockFd = nil
+}
+
+// getLockHolder returns description of process holding the lock
+func getLockHolder(lockFile string) string {
+	holder := pid.Read(lockFile)
+
+	if holder <= 0 {
+		return "(PID unknown)"
+	}
+
+	return fmt.Sprintf("(PID %d)", holder)
+}
+
+// getLockFilePath returns path to install lock file
+func getLockFilePath() string {
+	return path.Join(knf.GetS(RBENV_DIR), LOCK_FILE)
+}
@@ -0,0 +1,69 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestLockTimeout(t *testing.T) {
+	setupTestRBEnv(t, "")
+
+	lockFile := getLockFilePath()
+
+	// Another holder of the lock
+	holder, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		t.Fatalf("Can't open lock file: %v", err)
+	}
+
+	defer holder.Close()
+
+	err = syscall.Flock(int(holder.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err != nil {
+		t.Fatalf("Can't lock file: %v", err)
+	}
+
+	holder.WriteString("12345\n")
+
+	fd, err := os.OpenFile(lockFile, os.O_RDWR, 0644)
+
+	if err != nil {
+		t.Fatalf("Can't open lock file: %v", err)
+	}
+
+	defer fd.Close()
+
+	start := time.Now()
+	err = waitForLock(fd, lockFile, 300*time.Millisecond)
+
+	switch {
+	case err == nil:
+		t.Fatalf("waitForLock must return error while lock is held by another process")
+	case !strings.Contains(err.Error(), "(PID 12345)"):
+		t.Errorf("Error doesn't contain lock holder: %v", err)
+	case time.Since(start) < 300*time.Millisecond:
+		t.Errorf("waitForLock returned error before timeout")
+	}
+
+	syscall.Flock(int(holder.Fd()), syscall.LOCK_UN)
+
+	err = waitForLock(fd, lockFile, time.Second)
+
+	if err != nil {
+		t.Errorf("waitForLock returned error for released lock: %v", err)
+	}
+}
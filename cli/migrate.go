@@ -0,0 +1,182 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// gemDirRegex is regex for parsing name of gem directory
+// (e.g. "net-http-0.4.1" or "nokogiri-1.15.4-x86_64-linux")
+var gemDirRegex = regexp.MustCompile(`^(.+?)-([0-9][0-9A-Za-z.]*)(-[A-Za-z].*)?$`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// migrateVersionGems migrates gems installed for one version to another version
+func migrateVersionGems(fromVersion, toVersion string) {
+	fromVersion, toVersion = getMigrationVersions(fromVersion, toVersion)
+
+	if options.GetB(OPT_DRY_RUN) {
+		printDryRunSteps([]*dryRunStep{getMigrateGemsDryRunStep(fromVersion, toVersion)})
+		return
+	}
+
+	checkRBEnv()
+
+	fmtc.Printfn("Migrating gems from {*}%s{!} to {*}%s{!}…\n", fromVersion, toVersion)
+
+	failed := migrateGems(fromVersion, toVersion)
+
+	if isVersionRegistered(toVersion) {
+		err := updateReceiptGems(toVersion)
+
+		if err != nil {
+			printWarn("Can't update install receipt for %s: %v", toVersion, err)
+		}
+	}
+
+	rehashShims()
+
+	fmtc.NewLine()
+
+	if len(failed) != 0 {
+		fmtc.Println("{r}Some gems can't be installed:{!}\n")
+
+		for _, err := range failed {
+			fmtc.Printfn("  {r}•{!} %s", err)
+		}
+
+		fmtc.NewLine()
+
+		printErrorAndExit("Gems migration finished with errors")
+	}
+
+	fmtc.Println("{g}All gems successfully migrated!{!}")
+}
+
+// migrateGems installs gems installed for one version to another version.
+// Returns slice with errors for gems which can't be installed.
+func migrateGems(fromVersion, toVersion string) []string {
+	var failed []string
+
+	gems := getGemsToMigrate(fromVersion, toVersion)
+
+	if len(gems) == 0 {
+		fmtc.Println("{s}There are no gems to migrate{!}")
+		return nil
+	}
+
+	for _, gemName := range slices.Sorted(maps.Keys(gems)) {
+		startTask("Installing %s (%s)", gemName, gems[gemName])
+		// Gems must be installed with exactly the same versions
+		_, err := runGemCmd(toVersion, "install", gemName, "="+gems[gemName])
+		doneTask(err == nil)
+
+		if err != nil {
+			log.Error("Can't migrate gem %s (%s) to %s: %v", gemName, gems[gemName], toVersion, err)
+			failed = append(failed, err.Error())
+			continue
+		}
+
+		log.Info(
+			"[%s] Gem %s (%s) migrated from %s to %s", currentUser.RealName,
+			gemName, gems[gemName], fromVersion, toVersion,
+		)
+	}
+
+	return failed
+}
+
+// getMigrationVersions checks source and target versions and returns names
+// of their directories
+func getMigrationVersions(fromVersion, toVersion string) (string, string) {
+	if fromVersion == "" || toVersion == "" {
+		printErrorAndExit("You must define source and target versions")
+	}
+
+	fromName, err := getInstalledVersionName(fromVersion)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	toName, err := getInstalledVersionName(toVersion)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	if fromName == toName {
+		printErrorAndExit("Source and target versions must be different")
+	}
+
+	return fromName, toName
+}
+
+// getMigrateGemsDryRunStep returns info about changes which would be made
+// by gems migration
+func getMigrateGemsDryRunStep(fromVersion, toVersion string) *dryRunStep {
+	step := &dryRunStep{Action: "migrate-gems", Version: toVersion}
+	gems := getGemsToMigrate(fromVersion, toVersion)
+
+	for _, gemName := range slices.Sorted(maps.Keys(gems)) {
+		step.Gems = append(step.Gems, fmt.Sprintf("install %s (%s)", gemName, gems[gemName]))
+	}
+
+	step.Problems = append(step.Problems, getRBEnvProblems()...)
+
+	return step
+}
+
+// getGemsToMigrate returns gems installed for one version and missing
+// for another version
+func getGemsToMigrate(fromVersion, toVersion string) map[string]string {
+	result := getVersionGems(fromVersion)
+
+	for gemName := range getVersionGems(toVersion) {
+		delete(result, gemName)
+	}
+
+	return result
+}
+
+// getVersionGems returns map with the latest versions of gems installed
+// for given version
+func getVersionGems(rubyVersion string) map[string]string {
+	result := make(map[string]string)
+	gemsDir := getVersionGemDirPath(rubyVersion)
+
+	if gemsDir == "" {
+		return result
+	}
+
+	for _, gem := range fsutil.List(gemsDir, true, fsutil.ListingFilter{Perms: "D"}) {
+		m := gemDirRegex.FindStringSubmatch(gem)
+
+		if m == nil {
+			continue
+		}
+
+		if result[m[1]] == "" || sortutil.VersionCompare(result[m[1]], m[2]) {
+			result[m[1]] = m[2]
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,311 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/spinner"
+	"github.com/essentialkaos/ek/v13/terminal"
+
+	"github.com/essentialkaos/rbinstall/format"
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// actionResult contains info about result of action
+type actionResult struct {
+	Action   string          `json:"action"`
+	Version  string          `json:"version,omitempty"`
+	Success  bool            `json:"success"`
+	Error    string          `json:"error,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Tasks    []*taskResult   `json:"tasks,omitempty"`
+	Results  []*actionResult `json:"results,omitempty"`
+	DryRun   []*dryRunStep   `json:"dry_run,omitempty"`
+	Verify   []*verifyResult `json:"verify,omitempty"`
+	Started  time.Time       `json:"started"`
+	Duration float64         `json:"duration"` // Duration in seconds
+}
+
+// taskResult contains info about result of action task
+type taskResult struct {
+	Name     string    `json:"name"`
+	Success  bool      `json:"success"`
+	Started  time.Time `json:"-"`
+	Duration float64   `json:"duration"` // Duration in seconds
+}
+
+// versionRecord contains info about version
+type versionRecord struct {
+	Category    string           `json:"category"`
+	Name        string           `json:"name"`
+	URL         string           `json:"url,omitempty"`
+	Size        int64            `json:"size"`
+	Hash        string           `json:"hash"`
+	Added       time.Time        `json:"added"`
+	EOL         bool             `json:"eol"`
+	Installed   bool             `json:"installed"`
+	InstallDate *time.Time       `json:"install_date,omitempty"`
+	Outdated    bool             `json:"outdated,omitempty"`
+	Receipt     *installReceipt  `json:"receipt,omitempty"`
+	Variations  []*versionRecord `json:"variations,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// outputFormat is format of machine-readable output
+var outputFormat string
+
+// formattedOutput is original stdout used for machine-readable output
+var formattedOutput *os.File
+
+// rootResult is result of current action
+var rootResult *actionResult
+
+// curResult is result of current action or sub-action
+var curResult *actionResult
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// configureOutputFormat configures machine-readable output
+func configureOutputFormat() {
+	if !options.Has(OPT_FORMAT) {
+		return
+	}
+
+	outputFormat = strings.ToLower(options.GetS(OPT_FORMAT))
+
+	if !format.IsSupported(outputFormat) {
+		terminal.Error("Unsupported output format %q", outputFormat)
+		os.Exit(1)
+	}
+
+	// All human-readable output goes to stderr
+	formattedOutput, os.Stdout = os.Stdout, os.Stderr
+
+	spinner.DisableAnimation = true
+	noProgress = true
+}
+
+// printFormatted prints given data in machine-readable format
+func printFormatted(v any) {
+	data, err := format.Marshal(v, outputFormat)
+
+	if err != nil {
+		terminal.Error("Can't encode output data: %v", err)
+		return
+	}
+
+	formattedOutput.Write(data)
+}
+
+// startAction starts recording of action result
+func startAction(action, version string) {
+	if outputFormat == "" {
+		return
+	}
+
+	rootResult = &actionResult{Action: action, Version: version, Started: time.Now()}
+	curResult = rootResult
+}
+
+// startSubAction starts recording of sub-action result
+func startSubAction(action, version string) {
+	if rootResult == nil {
+		return
+	}
+
+	curResult = &actionResult{Action: action, Version: version, Started: time.Now()}
+	rootResult.Results = append(rootResult.Results, curResult)
+}
+
+// finishSubAction finishes recording of sub-action result
+func finishSubAction() {
+	if curResult == nil || curResult == rootResult {
+		return
+	}
+
+	curResult.Success = curResult.Error == ""
+	curResult.Duration = time.Since(curResult.Started).Seconds()
+	curResult = rootResult
+}
+
+// printActionResult prints result of action
+func printActionResult(code int) {
+	if rootResult == nil {
+		return
+	}
+
+	finishSubAction()
+
+	rootResult.Success = code == 0 && rootResult.Error == ""
+	rootResult.Duration = time.Since(rootResult.Started).Seconds()
+
+	printFormatted(rootResult)
+
+	rootResult, curResult = nil, nil
+}
+
+// startTask shows spinner and starts recording of task result
+func startTask(message string, args ...any) {
+	spinner.Show(message, args...)
+
+	if curResult == nil {
+		return
+	}
+
+	curResult.Tasks = append(curResult.Tasks, &taskResult{
+		Name:    fmtc.Clean(fmt.Sprintf(message, args...)),
+		Started: time.Now(),
+	})
+}
+
+// doneTask hides spinner and finishes recording of task result
+func doneTask(ok bool) {
+	spinner.Done(ok)
+
+	if curResult == nil || len(curResult.Tasks) == 0 {
+		return
+	}
+
+	task := curResult.Tasks[len(curResult.Tasks)-1]
+
+	if task.Duration != 0 {
+		return
+	}
+
+	task.Success = ok
+	task.Duration = time.Since(task.Started).Seconds()
+}
+
+// addTaskResult records result of task which was executed without spinner
+func addTaskResult(name string, ok bool, started time.Time) {
+	if curResult == nil {
+		return
+	}
+
+	task := &taskResult{Name: name, Success: ok, Started: started}
+
+	if !started.IsZero() {
+		task.Duration = time.Since(started).Seconds()
+	}
+
+	curResult.Tasks = append(curResult.Tasks, task)
+}
+
+// printError prints error message and saves it to action result
+func printError(message any, args ...any) {
+	terminal.Error(message, args...)
+	saveActionError(message, args...)
+}
+
+// printWarn prints warning message and saves it to action result
+func printWarn(message any, args ...any) {
+	terminal.Warn(message, args...)
+
+	if curResult != nil {
+		curResult.Warnings = append(curResult.Warnings, formatResultMessage(message, args))
+	}
+}
+
+// saveActionError saves error message to action result
+func saveActionError(message any, args ...any) {
+	if curResult == nil {
+		return
+	}
+
+	curResult.Error = formatResultMessage(message, args)
+
+	if curResult != rootResult {
+		rootResult.Error = curResult.Error
+	}
+}
+
+// formatResultMessage formats message for action result
+func formatResultMessage(message any, args []any) string {
+	var result string
+
+	switch m := message.(type) {
+	case string:
+		if len(args) == 0 {
+			result = m
+		} else {
+			result = fmt.Sprintf(m, args...)
+		}
+	default:
+		result = fmt.Sprint(message)
+	}
+
+	return strings.TrimSpace(fmtc.Clean(result))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// printFormattedListing prints versions listing in machine-readable format
+func printFormattedListing(dist, arch string) {
+	var result []*versionRecord
+
+	installed := getInstalledVersionsMap()
+
+	for _, category := range []string{
+		index.CATEGORY_RUBY, index.CATEGORY_JRUBY,
+		index.CATEGORY_TRUFFLE, index.CATEGORY_OTHER,
+	} {
+		versions := filterCategoryData(
+			repoIndex.GetCategoryData(dist, arch, category, true),
+			installed,
+		)
+
+		for _, info := range versions {
+			result = append(result, getVersionRecord(info, category))
+		}
+	}
+
+	if result == nil {
+		result = []*versionRecord{}
+	}
+
+	printFormatted(result)
+}
+
+// getVersionRecord creates version record for given version
+func getVersionRecord(info *index.VersionInfo, category string) *versionRecord {
+	record := &versionRecord{
+		Category:  category,
+		Name:      info.Name,
+		Size:      info.Size,
+		Hash:      info.Hash,
+		Added:     time.Unix(info.Added, 0),
+		EOL:       info.EOL,
+		Installed: isVersionInstalled(info.Name),
+	}
+
+	if record.Installed {
+		installDate, err := getInstallDate(info.Name)
+
+		if err == nil {
+			record.InstallDate = &installDate
+		}
+
+		record.Outdated = isVersionOutdated(info)
+		record.Receipt, _ = getInstallReceipt(info.Name)
+	}
+
+	for _, variation := range info.Variations {
+		record.Variations = append(record.Variations, getVersionRecord(variation, category))
+	}
+
+	return record
+}
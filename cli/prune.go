@@ -0,0 +1,256 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// pruneCandidate contains info about installed version which can be pruned
+type pruneCandidate struct {
+	Version string
+	Reasons []string
+}
+
+// versionReference contains info about version file in project directory
+type versionReference struct {
+	File    string
+	Format  string
+	Version string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// pruneSkipDirs contains names of directories which are skipped while searching
+// for projects
+var pruneSkipDirs = []string{"node_modules", "vendor", "tmp", "log"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// pruneVersions uninstalls unused EOL and superseded versions
+func pruneVersions() {
+	candidates := getPruneCandidates()
+
+	if len(candidates) == 0 {
+		fmtc.Println("{g}There is nothing to prune{!}")
+		return
+	}
+
+	fmtc.Println("{*}Versions to prune:{!}\n")
+
+	for _, candidate := range candidates {
+		fmtc.Printfn(
+			"  {*}%s{!} {s}— %s{!}",
+			candidate.Version, strings.Join(candidate.Reasons, ", "),
+		)
+	}
+
+	fmtc.NewLine()
+
+	if options.GetB(OPT_DRY_RUN) {
+		var steps []*dryRunStep
+
+		for _, candidate := range candidates {
+			steps = append(steps, getUninstallDryRunStep(candidate.Version))
+		}
+
+		printDryRunSteps(steps)
+		return
+	}
+
+	if !knf.GetB(RBENV_ALLOW_UNINSTALL, false) {
+		printErrorAndExit("Uninstalling is not allowed")
+	}
+
+	if !options.GetB(OPT_YES) {
+		ok, err := input.ReadAnswer("Uninstall these versions?", "N")
+
+		if err != nil || !ok {
+			return
+		}
+
+		fmtc.NewLine()
+	}
+
+	var hasErrors bool
+
+	for _, candidate := range candidates {
+		startSubAction("uninstall", candidate.Version)
+
+		startTask("Uninstalling %s", candidate.Version)
+		err := uninstallTaskHandler(candidate.Version)
+		doneTask(err == nil)
+
+		if err != nil {
+			printWarn("Can't uninstall %s: %v", candidate.Version, err)
+			saveActionError(err.Error())
+			hasErrors = true
+		} else {
+			log.Info(
+				"[%s] Uninstalled version %s (pruned: %s)", currentUser.RealName,
+				candidate.Version, strings.Join(candidate.Reasons, ", "),
+			)
+		}
+
+		finishSubAction()
+	}
+
+	rehashShims()
+
+	fmtc.NewLine()
+
+	if hasErrors {
+		printErrorAndExit("Some versions can't be uninstalled")
+	}
+
+	fmtc.Println("{g}Unused versions successfully pruned{!}")
+}
+
+// getPruneCandidates returns slice with versions which can be pruned
+func getPruneCandidates() []*pruneCandidate {
+	var result []*pruneCandidate
+
+	versions := getInstalledVersionsList()
+	usedVersions := getUsedVersions(versions)
+	checkUsage := len(knf.GetL(PRUNE_PROJECT_ROOTS)) != 0
+
+	for _, versionName := range versions {
+		// Versions installed by other tools are never pruned
+		if !isVersionRegistered(versionName) || usedVersions[versionName] {
+			continue
+		}
+
+		var reasons []string
+
+		info, _, err := getVersionInfo(versionName)
+
+		if err == nil && info.EOL {
+			reasons = append(reasons, "EOL")
+		}
+
+		for _, otherVersion := range versions {
+			if index.IsSuperseded(versionName, otherVersion) {
+				reasons = append(reasons, "superseded by "+otherVersion)
+				break
+			}
+		}
+
+		if len(reasons) == 0 {
+			continue
+		}
+
+		if checkUsage {
+			reasons = append(reasons, "not used")
+		}
+
+		result = append(result, &pruneCandidate{versionName, reasons})
+	}
+
+	return result
+}
+
+// getUsedVersions returns map with installed versions used as global version
+// or referenced by projects in project directories
+func getUsedVersions(versions []string) map[string]bool {
+	result := make(map[string]bool)
+	globalVersion, err := readRubyVersionFile(path.Join(knf.GetS(RBENV_DIR), "version"))
+
+	if err == nil {
+		result[findInstalledVersion(globalVersion, versions)] = true
+	}
+
+	for _, projectsDir := range knf.GetL(PRUNE_PROJECT_ROOTS) {
+		if !fsutil.IsDir(projectsDir) {
+			printWarn("Project directory %s doesn't exist", projectsDir)
+			continue
+		}
+
+		for _, ref := range findVersionReferences(projectsDir, knf.GetI(PRUNE_SCAN_DEPTH, 4)) {
+			result[findInstalledVersion(ref.Version, versions)] = true
+		}
+	}
+
+	delete(result, "")
+
+	return result
+}
+
+// findVersionReferences returns info about version files in given directory
+// and its subdirectories
+func findVersionReferences(dir string, maxDepth int) []*versionReference {
+	var result []*versionReference
+
+	filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		if file != dir {
+			if strings.HasPrefix(d.Name(), ".") || slices.Contains(pruneSkipDirs, d.Name()) {
+				return filepath.SkipDir
+			}
+
+			relPath, _ := filepath.Rel(dir, file)
+
+			if strings.Count(relPath, string(filepath.Separator))+1 > maxDepth {
+				return filepath.SkipDir
+			}
+		}
+
+		for _, format := range versionFileFormats {
+			versionFile := path.Join(file, format.Name)
+			versionName, err := format.Reader(versionFile)
+
+			if err == nil && versionName != "" {
+				result = append(result, &versionReference{
+					versionFile, format.Name, normalizeVersionName(versionName),
+				})
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return result
+}
+
+// findInstalledVersion returns name of installed version which will be used
+// for given version name or constraint
+func findInstalledVersion(ref string, versions []string) string {
+	var result string
+
+	c, err := index.ParseConstraint(ref)
+
+	for _, versionName := range versions {
+		switch {
+		case versionName == ref, getNameWithoutPatchLevel(versionName) == ref:
+			return versionName
+		case err == nil && c.Match(versionName):
+			// Versions are sorted, so the newest matching version will be used
+			result = versionName
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,244 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/sortutil"
+	"github.com/essentialkaos/ek/v13/strutil"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// REGISTRY_DIR is name of directory with install registry in rbenv directory
+const REGISTRY_DIR = ".rbinstall"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// installReceipt contains info about version installed by rbinstall
+type installReceipt struct {
+	Version    string            `json:"version"`            // Version name
+	File       string            `json:"file"`               // Archive name
+	Hash       string            `json:"hash"`               // Archive SHA-256 hash
+	Added      int64             `json:"added"`              // Date when archive was added to repo
+	IndexUUID  string            `json:"index_uuid"`         // UUID of index used for install
+	StorageURL string            `json:"storage_url"`        // Storage URL
+	RubyGems   string            `json:"rubygems,omitempty"` // Installed RubyGems version
+	Gems       map[string]string `json:"gems,omitempty"`     // Installed gems with versions
+	User       string            `json:"user"`               // Name of user who installed version
+	Installed  time.Time         `json:"installed"`          // Install date
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// registerVersion adds receipt for installed version to install registry
+func registerVersion(info *index.VersionInfo) error {
+	receipt := &installReceipt{
+		Version:    info.Name,
+		File:       info.File,
+		Hash:       info.Hash,
+		Added:      info.Added,
+		IndexUUID:  getIndexUUID(),
+		StorageURL: repoStorageURL,
+		User:       currentUser.RealName,
+		Installed:  time.Now(),
+	}
+
+	receipt.RubyGems, receipt.Gems = getGemsState(info.Name)
+
+	return saveInstallReceipt(receipt)
+}
+
+// updateReceiptGems updates info about installed gems in receipt for
+// given version
+func updateReceiptGems(rubyVersion string) error {
+	receipt, err := getInstallReceipt(rubyVersion)
+
+	if err != nil {
+		return err
+	}
+
+	receipt.RubyGems, receipt.Gems = getGemsState(rubyVersion)
+
+	return saveInstallReceipt(receipt)
+}
+
+// saveInstallReceipt saves receipt to install registry
+func saveInstallReceipt(receipt *installReceipt) error {
+	registryDir := getRegistryDirPath()
+
+	if !fsutil.IsExist(registryDir) {
+		err := os.Mkdir(registryDir, 0755)
+
+		if err != nil {
+			return fmt.Errorf("Can't create registry directory: %w", err)
+		}
+	}
+
+	receiptFile := getRegistryRecordPath(receipt.Version)
+	err := jsonutil.Write(receiptFile+".tmp", receipt, 0644)
+
+	if err != nil {
+		os.Remove(receiptFile + ".tmp")
+		return fmt.Errorf("Can't save install receipt: %w", err)
+	}
+
+	return os.Rename(receiptFile+".tmp", receiptFile)
+}
+
+// unregisterVersion removes info about version from install registry
+func unregisterVersion(rubyVersion string) error {
+	recordFile := getRegistryRecordPath(rubyVersion)
+
+	if !fsutil.IsExist(recordFile) {
+		return nil
+	}
+
+	return os.Remove(recordFile)
+}
+
+// getInstallReceipt reads receipt for installed version from install registry
+func getInstallReceipt(rubyVersion string) (*installReceipt, error) {
+	receipt := &installReceipt{}
+	err := jsonutil.Read(getRegistryRecordPath(rubyVersion), receipt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// isVersionRegistered returns true if given version was installed by rbinstall
+func isVersionRegistered(rubyVersion string) bool {
+	return fsutil.IsExist(getRegistryRecordPath(rubyVersion))
+}
+
+// getInstalledVersionName returns name of directory with installed version.
+// Versions which are not present in index and have no install receipt (e.g.
+// installed before registry was introduced) are treated as versions with
+// unknown origin.
+func getInstalledVersionName(rubyVersion string) (string, error) {
+	if rubyVersion == "" || strings.ContainsAny(rubyVersion, "/\\") || strings.HasPrefix(rubyVersion, ".") {
+		return "", fmt.Errorf("Invalid version name %q", rubyVersion)
+	}
+
+	info, _, err := getVersionInfo(rubyVersion)
+
+	if err == nil && isVersionInstalled(info.Name) {
+		return info.Name, nil
+	}
+
+	versionName := rubyVersion
+
+	// Alias for version with -p0 suffix
+	if fsutil.IsLink(getVersionPath(versionName)) {
+		target, _ := os.Readlink(getVersionPath(versionName))
+		versionName = path.Base(target)
+	}
+
+	if !fsutil.IsDir(getVersionPath(versionName)) {
+		return "", fmt.Errorf("Version %s is not installed", rubyVersion)
+	}
+
+	return versionName, nil
+}
+
+// getInstallDate returns date when given version was installed
+func getInstallDate(rubyVersion string) (time.Time, error) {
+	receipt, err := getInstallReceipt(rubyVersion)
+
+	if err == nil {
+		return receipt.Installed, nil
+	}
+
+	// Versions installed before registry was introduced have no receipts
+	return fsutil.GetMTime(getVersionPath(rubyVersion))
+}
+
+// getGemsState returns RubyGems version and versions of configured gems
+// installed for given version
+func getGemsState(rubyVersion string) (string, map[string]string) {
+	var rgVersion string
+
+	rubyPath := getVersionPath(rubyVersion)
+	output, err := exec.Command(rubyPath+"/bin/ruby", rubyPath+"/bin/gem", "--version").Output()
+
+	if err == nil {
+		rgVersion = strings.TrimSpace(string(output))
+	}
+
+	gems := make(map[string]string)
+
+	for _, gem := range getGemSet(rubyVersion).Install {
+		gemName, _ := parseGemInfo(gem)
+		gemVersion := getLatestGemVersion(rubyVersion, gemName)
+
+		if gemVersion != "" {
+			gems[gemName] = gemVersion
+		}
+	}
+
+	if len(gems) == 0 {
+		return rgVersion, nil
+	}
+
+	return rgVersion, gems
+}
+
+// getLatestGemVersion returns the latest installed version of given gem
+func getLatestGemVersion(rubyVersion, gemName string) string {
+	gemsDir := getVersionGemDirPath(rubyVersion)
+
+	if gemsDir == "" {
+		return ""
+	}
+
+	var versions []string
+
+	for _, gem := range fsutil.List(gemsDir, true) {
+		gemVersion, ok := strings.CutPrefix(gem, gemName+"-")
+
+		// Version must start with digit (e.g. "rake-13.0.6", but not "rake-compiler-1.2.5")
+		if !ok || gemVersion == "" || gemVersion[0] < '0' || gemVersion[0] > '9' {
+			continue
+		}
+
+		// Remove platform suffix (e.g. "nokogiri-1.15.4-x86_64-linux")
+		versions = append(versions, strutil.ReadField(gemVersion, 0, false, '-'))
+	}
+
+	if len(versions) == 0 {
+		return ""
+	}
+
+	sortutil.Versions(versions)
+
+	return versions[len(versions)-1]
+}
+
+// getRegistryDirPath returns path to install registry directory
+func getRegistryDirPath() string {
+	return path.Join(knf.GetS(RBENV_DIR), REGISTRY_DIR)
+}
+
+// getRegistryRecordPath returns path to install receipt for given version
+func getRegistryRecordPath(rubyVersion string) string {
+	return path.Join(getRegistryDirPath(), rubyVersion+".json")
+}
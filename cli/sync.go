@@ -0,0 +1,325 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MANIFEST_SECTION is name of manifest section with sync options
+const MANIFEST_SECTION = "sync"
+
+// Manifest properties
+const (
+	MANIFEST_UNINSTALL_EXTRA   = "sync:uninstall-extra"
+	MANIFEST_REINSTALL_UPDATED = "sync:reinstall-updated"
+	MANIFEST_GEMS              = "gems"
+)
+
+// Sync actions
+const (
+	SYNC_ACTION_INSTALL   = "install"
+	SYNC_ACTION_REINSTALL = "reinstall"
+	SYNC_ACTION_GEMS      = "install-gems"
+	SYNC_ACTION_UNINSTALL = "uninstall"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// syncStep is step of sync plan
+type syncStep struct {
+	Action  string
+	Version string
+	Gems    []string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// syncVersions installs, reinstalls and uninstalls versions to match given manifest
+func syncVersions(file string) {
+	manifest, err := knf.Read(file)
+
+	if err != nil {
+		printErrorAndExit("Can't read manifest %s: %v", file, err)
+	}
+
+	checkIndexSignature()
+
+	plan, err := getSyncPlan(manifest)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	if len(plan) == 0 {
+		fmtc.Println("{g}All versions are in sync with manifest{!}")
+		return
+	}
+
+	printSyncPlan(plan)
+
+	if options.GetB(OPT_DRY_RUN) {
+		fmtc.NewLine()
+		printDryRunSteps(getSyncDryRunSteps(plan))
+		return
+	}
+
+	checkSyncPlanPerms(plan)
+
+	var hasErrors bool
+
+	for _, step := range plan {
+		fmtutil.Separator(false, step.Version)
+		fmtc.NewLine()
+
+		startSubAction(step.Action, step.Version)
+
+		var err error
+
+		switch step.Action {
+		case SYNC_ACTION_INSTALL:
+			err = installVersion(step.Version, false)
+		case SYNC_ACTION_REINSTALL:
+			err = reinstallVersion(step.Version)
+		case SYNC_ACTION_UNINSTALL:
+			err = uninstallVersion(step.Version)
+		}
+
+		if err == errCanceled {
+			printErrorAndExit(err.Error())
+		}
+
+		// Failed step doesn't stop sync, other steps must be applied anyway
+		if err != nil {
+			fmtc.NewLine()
+			printError(err.Error())
+			hasErrors = true
+		} else if len(step.Gems) != 0 && !installManifestGems(step.Version, step.Gems) {
+			hasErrors = true
+		}
+
+		finishSubAction()
+	}
+
+	fmtc.NewLine()
+
+	if hasErrors {
+		printErrorAndExit("Sync finished with errors")
+	}
+
+	log.Info("[%s] Versions synced with manifest %s", currentUser.RealName, file)
+	fmtc.Println("{g}All versions successfully synced with manifest{!}")
+}
+
+// getSyncPlan creates plan for syncing installed versions with manifest
+func getSyncPlan(manifest *knf.Config) ([]*syncStep, error) {
+	var plan []*syncStep
+
+	required := make(map[string]bool)
+	reinstallUpdated := manifest.GetB(MANIFEST_REINSTALL_UPDATED, false)
+
+	for _, section := range manifest.Sections() {
+		if strings.ToLower(section) == MANIFEST_SECTION {
+			continue
+		}
+
+		rubyVersion, err := getResolvedVersionName(section)
+
+		if err != nil {
+			return nil, err
+		}
+
+		info, _, err := getVersionInfo(rubyVersion)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if required[info.Name] {
+			return nil, fmt.Errorf("Version %s defined in manifest more than once", info.Name)
+		}
+
+		required[info.Name] = true
+		gems := manifest.GetL(knf.Q(section, MANIFEST_GEMS))
+
+		switch {
+		case !isVersionInstalled(info.Name):
+			plan = append(plan, &syncStep{SYNC_ACTION_INSTALL, info.Name, gems})
+
+		case reinstallUpdated && isVersionOutdated(info):
+			plan = append(plan, &syncStep{SYNC_ACTION_REINSTALL, info.Name, gems})
+
+		default:
+			missingGems := getMissingGems(info.Name, gems)
+
+			if len(missingGems) != 0 {
+				plan = append(plan, &syncStep{SYNC_ACTION_GEMS, info.Name, missingGems})
+			}
+		}
+	}
+
+	if !manifest.GetB(MANIFEST_UNINSTALL_EXTRA, false) {
+		return plan, nil
+	}
+
+	var extra []string
+
+	for rubyVersion := range getInstalledVersionsMap() {
+		// Skip required versions and aliases for versions with -p0 suffix
+		if required[rubyVersion] || fsutil.IsLink(getVersionPath(rubyVersion)) {
+			continue
+		}
+
+		extra = append(extra, rubyVersion)
+	}
+
+	sortutil.Versions(extra)
+
+	for _, rubyVersion := range extra {
+		// Versions installed by other tools are never uninstalled
+		if !isVersionRegistered(rubyVersion) {
+			printWarn("Version %s wasn't installed by rbinstall, it will be kept", rubyVersion)
+			continue
+		}
+
+		plan = append(plan, &syncStep{SYNC_ACTION_UNINSTALL, rubyVersion, nil})
+	}
+
+	return plan, nil
+}
+
+// printSyncPlan prints sync plan
+func printSyncPlan(plan []*syncStep) {
+	fmtc.Println("{*}Sync plan:{!}\n")
+
+	for _, step := range plan {
+		switch step.Action {
+		case SYNC_ACTION_INSTALL:
+			fmtc.Printf("  {g}+{!} %-24s {s}install{!}", step.Version)
+		case SYNC_ACTION_REINSTALL:
+			fmtc.Printf("  {y}↻{!} %-24s {s}reinstall (rebuilt){!}", step.Version)
+		case SYNC_ACTION_GEMS:
+			fmtc.Printf("  {c}*{!} %-24s {s}install gems{!}", step.Version)
+		case SYNC_ACTION_UNINSTALL:
+			fmtc.Printf("  {r}−{!} %-24s {s}uninstall{!}", step.Version)
+		}
+
+		if len(step.Gems) != 0 {
+			fmtc.Printf(" {s-}(%s){!}", strings.Join(step.Gems, ", "))
+		}
+
+		fmtc.NewLine()
+	}
+}
+
+// checkSyncPlanPerms checks that all actions from sync plan are allowed
+func checkSyncPlanPerms(plan []*syncStep) {
+	for _, step := range plan {
+		switch {
+		case step.Action == SYNC_ACTION_REINSTALL && !knf.GetB(RBENV_ALLOW_OVERWRITE, false):
+			fmtc.NewLine()
+			printErrorAndExit("Can't sync versions: reinstalling is not allowed")
+		case step.Action == SYNC_ACTION_UNINSTALL && !knf.GetB(RBENV_ALLOW_UNINSTALL, false):
+			fmtc.NewLine()
+			printErrorAndExit("Can't sync versions: uninstalling is not allowed")
+		}
+	}
+}
+
+// getSyncDryRunSteps returns info about changes which would be made by
+// executing sync plan
+func getSyncDryRunSteps(plan []*syncStep) []*dryRunStep {
+	var result []*dryRunStep
+
+	for _, step := range plan {
+		var dryStep *dryRunStep
+
+		switch step.Action {
+		case SYNC_ACTION_INSTALL:
+			dryStep = getInstallDryRunStep(step.Version, false)
+		case SYNC_ACTION_REINSTALL:
+			dryStep = getInstallDryRunStep(step.Version, true)
+		case SYNC_ACTION_UNINSTALL:
+			dryStep = getUninstallDryRunStep(step.Version)
+		default:
+			dryStep = &dryRunStep{Action: step.Action, Version: step.Version}
+		}
+
+		for _, gem := range step.Gems {
+			gemName, gemVersion := parseGemInfo(gem)
+			dryStep.Gems = append(dryStep.Gems, fmt.Sprintf("install %s (%s)", gemName, formatGemVersion(gemVersion)))
+		}
+
+		result = append(result, dryStep)
+	}
+
+	return result
+}
+
+// installManifestGems installs gems defined in manifest
+func installManifestGems(rubyVersion string, gems []string) bool {
+	var hasErrors bool
+
+	if len(gems) == 0 {
+		return true
+	}
+
+	fmtc.NewLine()
+
+	for _, gem := range gems {
+		gemName, gemVersion := parseGemInfo(gem)
+
+		startTask("Installing %s (%s)", gemName, formatGemVersion(gemVersion))
+		installedVersion, err := installGemTaskHandler(rubyVersion, gemName, gemVersion)
+		doneTask(err == nil)
+
+		if err != nil {
+			printWarn(err.Error())
+			saveActionError(err.Error())
+			hasErrors = true
+			continue
+		}
+
+		if installedVersion != "" {
+			log.Info(
+				"[%s] Gem %s installed with version %s for %s",
+				currentUser.RealName, gemName, installedVersion, rubyVersion,
+			)
+		}
+	}
+
+	rehashShims()
+
+	return !hasErrors
+}
+
+// getMissingGems returns slice with gems which are not installed for given version
+func getMissingGems(rubyVersion string, gems []string) []string {
+	var result []string
+
+	for _, gem := range gems {
+		gemName, _ := parseGemInfo(gem)
+
+		if !isGemInstalled(rubyVersion, gemName) {
+			result = append(result, gem)
+		}
+	}
+
+	return result
+}
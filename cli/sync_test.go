@@ -0,0 +1,112 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essentialkaos/ek/v13/knf"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestSyncPlanKeepsForeignVersions(t *testing.T) {
+	rbenvDir := setupTestRBEnv(t, "")
+
+	addTestVersion(t, rbenvDir, "3.2.1", true)
+	addTestVersion(t, rbenvDir, "3.3.0", true)
+	addTestVersion(t, rbenvDir, "3.1.0", false)
+	addTestVersion(t, rbenvDir, "truffleruby-24.1.0", false)
+
+	// Alias for version with -p0 suffix
+	addTestVersion(t, rbenvDir, "2.0.0-p0", true)
+	os.Symlink("2.0.0-p0", filepath.Join(rbenvDir, "versions", "2.0.0"))
+
+	manifest, err := knf.Parse([]byte("[sync]\n  uninstall-extra: true\n"))
+
+	if err != nil {
+		t.Fatalf("Can't parse manifest: %v", err)
+	}
+
+	plan, err := getSyncPlan(manifest)
+
+	if err != nil {
+		t.Fatalf("getSyncPlan returned error: %v", err)
+	}
+
+	expected := []string{"2.0.0-p0", "3.2.1", "3.3.0"}
+
+	if len(plan) != len(expected) {
+		t.Fatalf("Sync plan contains %d steps, want %d", len(plan), len(expected))
+	}
+
+	for i, step := range plan {
+		if step.Action != SYNC_ACTION_UNINSTALL || step.Version != expected[i] {
+			t.Errorf("Step #%d is %s %s, want uninstall %s", i, step.Action, step.Version, expected[i])
+		}
+	}
+
+	manifest, _ = knf.Parse([]byte("[sync]\n  uninstall-extra: false\n"))
+	plan, err = getSyncPlan(manifest)
+
+	if err != nil || len(plan) != 0 {
+		t.Errorf("Sync plan without uninstall-extra must be empty")
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// setupTestRBEnv creates temporary rbenv directory and global configuration
+// which uses it
+func setupTestRBEnv(t *testing.T, extraConfig string) string {
+	dir := t.TempDir()
+	rbenvDir := filepath.Join(dir, "rbenv")
+	configFile := filepath.Join(dir, "rbinstall.knf")
+
+	for _, d := range []string{"versions", REGISTRY_DIR} {
+		err := os.MkdirAll(filepath.Join(rbenvDir, d), 0755)
+
+		if err != nil {
+			t.Fatalf("Can't create rbenv directory: %v", err)
+		}
+	}
+
+	config := "[main]\n  tmp-dir: " + dir + "\n\n[rbenv]\n  dir: " + rbenvDir + "\n" + extraConfig
+	err := os.WriteFile(configFile, []byte(config), 0644)
+
+	if err == nil {
+		err = knf.Global(configFile)
+	}
+
+	if err != nil {
+		t.Fatalf("Can't create configuration: %v", err)
+	}
+
+	return rbenvDir
+}
+
+// addTestVersion creates directory for installed version and install receipt
+// (if version must be registered)
+func addTestVersion(t *testing.T, rbenvDir, versionName string, registered bool) {
+	versionDir := filepath.Join(rbenvDir, "versions", versionName)
+	err := os.MkdirAll(filepath.Join(versionDir, "bin"), 0755)
+
+	if err == nil {
+		err = os.WriteFile(filepath.Join(versionDir, "bin", "ruby"), []byte(versionName), 0755)
+	}
+
+	if err == nil && registered {
+		err = os.WriteFile(getRegistryRecordPath(versionName), []byte("{}"), 0644)
+	}
+
+	if err != nil {
+		t.Fatalf("Can't create version %s: %v", versionName, err)
+	}
+}
@@ -0,0 +1,274 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// installTransaction contains info about changes made to rbenv directory
+// during installation which can be rolled back
+type installTransaction struct {
+	version  string // Name of installing version
+	backup   string // Path to backup of previously installed version
+	alias    string // Path to created alias
+	applied  bool   // New version moved to rbenv directory
+	finished bool   // Transaction committed or rolled back
+
+	mx sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// installTx is current install transaction
+var installTx *installTransaction
+
+// isCanceled is true if install process was canceled by user
+var isCanceled bool
+
+// txMx protects current install transaction and cancel flag
+var txMx sync.Mutex
+
+// errTxFinished is returned if transaction is already finished
+var errTxFinished = errors.New("Install transaction already finished")
+
+// errCanceled is returned if install process was canceled by user
+var errCanceled = errors.New("Install process canceled by Ctrl+C")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// startInstallTransaction starts new install transaction for given version
+func startInstallTransaction(rubyVersion string) (*installTransaction, error) {
+	versionPath := getVersionPath(rubyVersion)
+	backupPath := getBackupPath(rubyVersion)
+
+	// Handle backup left after interrupted install
+	if fsutil.IsExist(backupPath) {
+		var err error
+
+		if fsutil.IsExist(versionPath) {
+			err = os.RemoveAll(backupPath)
+		} else {
+			err = os.Rename(backupPath, versionPath)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't handle backup left after previous install: %w", err)
+		}
+	}
+
+	tx := &installTransaction{version: rubyVersion}
+
+	// Transaction must be registered before any changes are made, so it
+	// can be rolled back if process is canceled
+	err := setInstallTx(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fsutil.IsExist(versionPath) {
+		err = os.Rename(versionPath, backupPath)
+
+		if err != nil {
+			setInstallTx(nil)
+			return nil, fmt.Errorf("Can't backup installed version: %w", err)
+		}
+
+		tx.backup = backupPath
+	}
+
+	return tx, nil
+}
+
+// Apply moves unpacked version to rbenv directory
+func (tx *installTransaction) Apply(unpackedDir string) error {
+	tx.mx.Lock()
+	defer tx.mx.Unlock()
+
+	if tx.finished {
+		return errTxFinished
+	}
+
+	err := os.Rename(unpackedDir, getVersionPath(tx.version))
+
+	if err != nil {
+		return err
+	}
+
+	tx.applied = true
+
+	return nil
+}
+
+// CreateAlias creates alias for installed version
+func (tx *installTransaction) CreateAlias(alias string) error {
+	tx.mx.Lock()
+	defer tx.mx.Unlock()
+
+	if tx.finished {
+		return errTxFinished
+	}
+
+	aliasPath := getVersionPath(alias)
+	err := os.Symlink(getVersionPath(tx.version), aliasPath)
+
+	if err != nil {
+		return err
+	}
+
+	tx.alias = aliasPath
+
+	return nil
+}
+
+// Commit finishes transaction and removes backup of previous version
+func (tx *installTransaction) Commit() error {
+	tx.mx.Lock()
+	defer tx.mx.Unlock()
+
+	if tx.finished {
+		return errTxFinished
+	}
+
+	tx.finished = true
+	setInstallTx(nil)
+
+	if tx.backup == "" {
+		return nil
+	}
+
+	return os.RemoveAll(tx.backup)
+}
+
+// Rollback reverts all changes made by transaction and restores previous version
+func (tx *installTransaction) Rollback() error {
+	tx.mx.Lock()
+	defer tx.mx.Unlock()
+
+	if tx.finished {
+		return errTxFinished
+	}
+
+	tx.finished = true
+	setInstallTx(nil)
+
+	if tx.alias != "" {
+		err := os.Remove(tx.alias)
+
+		if err != nil {
+			return fmt.Errorf("Can't remove alias %s: %w", tx.alias, err)
+		}
+	}
+
+	if tx.applied {
+		err := os.RemoveAll(getVersionPath(tx.version))
+
+		if err != nil {
+			return fmt.Errorf("Can't remove installed data: %w", err)
+		}
+	}
+
+	if tx.backup != "" {
+		err := os.Rename(tx.backup, getVersionPath(tx.version))
+
+		if err != nil {
+			return fmt.Errorf("Can't restore previous version from %s: %w", tx.backup, err)
+		}
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// setInstallTx sets current install transaction
+func setInstallTx(tx *installTransaction) error {
+	txMx.Lock()
+	defer txMx.Unlock()
+
+	if tx != nil && isCanceled {
+		return errCanceled
+	}
+
+	installTx = tx
+
+	return nil
+}
+
+// cancelInstall marks install process as canceled and returns true if there
+// is install transaction which must be rolled back
+func cancelInstall() bool {
+	txMx.Lock()
+	defer txMx.Unlock()
+
+	isCanceled = true
+
+	return installTx != nil
+}
+
+// checkCanceled returns error if install process was canceled by user
+func checkCanceled() error {
+	txMx.Lock()
+	defer txMx.Unlock()
+
+	if isCanceled {
+		return errCanceled
+	}
+
+	return nil
+}
+
+// rollbackInstall rolls back current install transaction
+func rollbackInstall() {
+	txMx.Lock()
+	tx := installTx
+	txMx.Unlock()
+
+	if tx == nil {
+		return
+	}
+
+	if tx.backup != "" {
+		startTask("Restoring previous installation of %s", tx.version)
+	} else {
+		startTask("Removing incomplete installation of %s", tx.version)
+	}
+
+	err := tx.Rollback()
+	doneTask(err == nil || err == errTxFinished)
+
+	if err != nil {
+		if err != errTxFinished {
+			log.Error("Can't rollback installation of %s: %v", tx.version, err)
+			printWarn(err.Error())
+		}
+
+		return
+	}
+
+	log.Info("Installation of %s rolled back", tx.version)
+
+	if rehashTaskHandler() != nil {
+		printWarn("Can't rehash rbenv shims after rollback")
+	}
+}
+
+// getBackupPath returns path to backup of installed version
+func getBackupPath(rubyVersion string) string {
+	return path.Join(getUnpackDirPath(), rubyVersion+".backup")
+}
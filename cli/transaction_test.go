@@ -0,0 +1,147 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestInstallTransactionRollback(t *testing.T) {
+	rbenvDir := setupTestRBEnv(t, "")
+
+	addTestVersion(t, rbenvDir, "3.3.0", true)
+
+	unpackedDir := createTestUnpackedDir(t, "3.3.0", "new")
+	tx, err := startInstallTransaction("3.3.0")
+
+	if err != nil {
+		t.Fatalf("startInstallTransaction returned error: %v", err)
+	}
+
+	if fsutil.IsExist(getVersionPath("3.3.0")) || !fsutil.IsExist(getBackupPath("3.3.0")) {
+		t.Fatalf("Installed version must be moved to backup")
+	}
+
+	// Reinstall fails after unpacked version is applied
+	if err = tx.Apply(unpackedDir); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	if err = tx.CreateAlias("3.3"); err != nil {
+		t.Fatalf("CreateAlias returned error: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(getVersionPath("3.3.0"), "bin", "ruby"))
+
+	if err != nil || string(data) != "3.3.0" {
+		t.Errorf("Previous version wasn't restored (%q, %v)", data, err)
+	}
+
+	if fsutil.IsExist(getBackupPath("3.3.0")) {
+		t.Errorf("Backup must be removed after rollback")
+	}
+
+	if fsutil.IsLink(getVersionPath("3.3")) {
+		t.Errorf("Alias must be removed after rollback")
+	}
+
+	if installTx != nil {
+		t.Errorf("Transaction must be unregistered after rollback")
+	}
+
+	if tx.Commit() != errTxFinished || tx.Rollback() != errTxFinished {
+		t.Errorf("Finished transaction must return errTxFinished")
+	}
+}
+
+func TestInstallTransactionCommit(t *testing.T) {
+	rbenvDir := setupTestRBEnv(t, "")
+
+	addTestVersion(t, rbenvDir, "3.3.0", true)
+
+	unpackedDir := createTestUnpackedDir(t, "3.3.0", "new")
+	tx, err := startInstallTransaction("3.3.0")
+
+	if err != nil {
+		t.Fatalf("startInstallTransaction returned error: %v", err)
+	}
+
+	if err = tx.Apply(unpackedDir); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(getVersionPath("3.3.0"), "bin", "ruby"))
+
+	if err != nil || string(data) != "new" {
+		t.Errorf("New version wasn't installed (%q, %v)", data, err)
+	}
+
+	if fsutil.IsExist(getBackupPath("3.3.0")) {
+		t.Errorf("Backup must be removed after commit")
+	}
+
+	if installTx != nil {
+		t.Errorf("Transaction must be unregistered after commit")
+	}
+}
+
+func TestInstallTransactionStaleBackup(t *testing.T) {
+	rbenvDir := setupTestRBEnv(t, "")
+
+	// Backup left by interrupted reinstall
+	addTestVersion(t, rbenvDir, "3.3.0", true)
+	os.MkdirAll(getUnpackDirPath(), 0755)
+	os.Rename(getVersionPath("3.3.0"), getBackupPath("3.3.0"))
+
+	tx, err := startInstallTransaction("3.3.0")
+
+	if err != nil {
+		t.Fatalf("startInstallTransaction returned error: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+
+	if !fsutil.IsExist(filepath.Join(getVersionPath("3.3.0"), "bin", "ruby")) {
+		t.Errorf("Version must be restored from stale backup")
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// createTestUnpackedDir creates directory with unpacked version data like
+// install process does before starting transaction
+func createTestUnpackedDir(t *testing.T, versionName, content string) string {
+	unpackedDir := filepath.Join(getUnpackDirPath(), versionName)
+	err := os.MkdirAll(filepath.Join(unpackedDir, "bin"), 0755)
+
+	if err == nil {
+		err = os.WriteFile(filepath.Join(unpackedDir, "bin", "ruby"), []byte(content), 0755)
+	}
+
+	if err != nil {
+		t.Fatalf("Can't create unpacked data: %v", err)
+	}
+
+	return unpackedDir
+}
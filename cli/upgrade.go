@@ -0,0 +1,275 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"slices"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// upgradeStep contains info about version upgrade
+type upgradeStep struct {
+	From string
+	To   *index.VersionInfo
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// updatableVersionFiles contains names of version files which can be
+// updated by upgrade
+var updatableVersionFiles = []string{".ruby-version", ".rbenv-version", "version"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// upgradeVersions upgrades given version (or all installed versions) to the
+// newest release in the same minor line
+func upgradeVersions(rubyVersion string) {
+	plan := getUpgradePlan(rubyVersion)
+
+	if len(plan) == 0 {
+		fmtc.Println("{g}All versions are up-to-date{!}")
+		return
+	}
+
+	fmtc.Println("{*}Versions to upgrade:{!}\n")
+
+	for _, step := range plan {
+		fmtc.Printfn("  {*}%s{!} → {*}%s{!}", step.From, step.To.Name)
+	}
+
+	fmtc.NewLine()
+
+	if options.GetB(OPT_DRY_RUN) {
+		printDryRunSteps(getUpgradeDryRunSteps(plan))
+		return
+	}
+
+	if options.GetB(OPT_REMOVE_OLD) && !knf.GetB(RBENV_ALLOW_UNINSTALL, false) {
+		printErrorAndExit("Uninstalling is not allowed")
+	}
+
+	var hasErrors bool
+
+	for _, step := range plan {
+		fmtutil.Separator(false, step.From+" → "+step.To.Name)
+
+		startSubAction("upgrade", step.From)
+
+		if !isVersionInstalled(step.To.Name) {
+			err := installVersion(step.To.Name, false)
+
+			if err != nil {
+				printErrorAndExit(err.Error())
+			}
+
+			fmtc.NewLine()
+		}
+
+		gemsMigrated := true
+
+		for _, err := range migrateGems(step.From, step.To.Name) {
+			printWarn(err)
+			hasErrors, gemsMigrated = true, false
+		}
+
+		if isVersionRegistered(step.To.Name) {
+			err := updateReceiptGems(step.To.Name)
+
+			if err != nil {
+				printWarn("Can't update install receipt for %s: %v", step.To.Name, err)
+			}
+		}
+
+		if options.GetB(OPT_UPDATE_REFS) {
+			updateVersionReferences(step.From, step.To.Name)
+		}
+
+		if options.GetB(OPT_REMOVE_OLD) {
+			removeOldVersion(step.From, gemsMigrated)
+		}
+
+		rehashShims()
+
+		log.Info("[%s] Version %s upgraded to %s", currentUser.RealName, step.From, step.To.Name)
+
+		finishSubAction()
+	}
+
+	fmtutil.Separator(false)
+
+	if hasErrors {
+		printErrorAndExit("Upgrade finished with errors")
+	}
+
+	fmtc.Println("{g}All versions successfully upgraded{!}")
+}
+
+// removeOldVersion uninstalls upgraded version if it is safe to do so
+func removeOldVersion(versionName string, gemsMigrated bool) {
+	switch {
+	case !isVersionRegistered(versionName):
+		printWarn("Version %s wasn't uninstalled because it wasn't installed by rbinstall", versionName)
+		return
+	case !gemsMigrated:
+		printWarn("Version %s wasn't uninstalled because some gems weren't migrated", versionName)
+		return
+	case isVersionUsed(versionName):
+		printWarn(
+			"Version %s wasn't uninstalled because it is still used (use option %s to update references)",
+			versionName, options.Format(OPT_UPDATE_REFS),
+		)
+		return
+	}
+
+	startTask("Uninstalling %s", versionName)
+	err := uninstallTaskHandler(versionName)
+	doneTask(err == nil)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	log.Info("[%s] Uninstalled version %s", currentUser.RealName, versionName)
+}
+
+// isVersionUsed returns true if given version is used as global version or
+// referenced by projects in project directories
+func isVersionUsed(versionName string) bool {
+	return getUsedVersions(getInstalledVersionsList())[versionName]
+}
+
+// getUpgradePlan returns slice with info about versions which can be upgraded
+func getUpgradePlan(rubyVersion string) []*upgradeStep {
+	var versions []string
+	var result []*upgradeStep
+
+	if rubyVersion != "" {
+		versionName, err := getInstalledVersionName(rubyVersion)
+
+		if err != nil {
+			printErrorAndExit(err.Error())
+		}
+
+		versions = append(versions, versionName)
+	} else {
+		for _, versionName := range getInstalledVersionsList() {
+			// Versions installed by other tools are never upgraded automatically
+			if !isVersionRegistered(versionName) {
+				printWarn("Version %s wasn't installed by rbinstall, it will be skipped", versionName)
+				continue
+			}
+
+			versions = append(versions, versionName)
+		}
+	}
+
+	dist, arch, err := getSystemInfo()
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	for _, versionName := range versions {
+		info, _ := repoIndex.FindUpgrade(dist, arch, versionName)
+
+		if info != nil {
+			result = append(result, &upgradeStep{versionName, info})
+		}
+	}
+
+	return result
+}
+
+// getUpgradeDryRunSteps returns info about changes which would be made
+// by upgrade
+func getUpgradeDryRunSteps(plan []*upgradeStep) []*dryRunStep {
+	var result []*dryRunStep
+
+	for _, step := range plan {
+		var installStep *dryRunStep
+
+		if isVersionInstalled(step.To.Name) {
+			installStep = &dryRunStep{Action: "upgrade", Version: step.To.Name}
+		} else {
+			installStep = getInstallDryRunStep(step.To.Name, false)
+			installStep.Action = "upgrade"
+		}
+
+		installStep.Gems = append(installStep.Gems, getMigrateGemsDryRunStep(step.From, step.To.Name).Gems...)
+
+		result = append(result, installStep)
+
+		// References are updated only by real upgrade, so with --update-refs
+		// we assume that old version will not be used anymore
+		if options.GetB(OPT_REMOVE_OLD) && isVersionRegistered(step.From) &&
+			(options.GetB(OPT_UPDATE_REFS) || !isVersionUsed(step.From)) {
+			result = append(result, getUninstallDryRunStep(step.From))
+		}
+	}
+
+	return result
+}
+
+// updateVersionReferences updates global version and version files which
+// reference old version
+func updateVersionReferences(fromVersion, toVersion string) {
+	var refs []*versionReference
+
+	globalVersionFile := path.Join(knf.GetS(RBENV_DIR), "version")
+	globalVersion, err := readRubyVersionFile(globalVersionFile)
+
+	if err == nil {
+		refs = append(refs, &versionReference{globalVersionFile, "version", normalizeVersionName(globalVersion)})
+	}
+
+	localVersion, localVersionFile, err := getVersionFromFile()
+
+	if err == nil {
+		refs = append(refs, &versionReference{localVersionFile, path.Base(localVersionFile), localVersion})
+	}
+
+	for _, projectsDir := range knf.GetL(PRUNE_PROJECT_ROOTS) {
+		refs = append(refs, findVersionReferences(projectsDir, knf.GetI(PRUNE_SCAN_DEPTH, 4))...)
+	}
+
+	updated := make(map[string]bool)
+
+	for _, ref := range refs {
+		if updated[ref.File] || !slices.Contains(updatableVersionFiles, ref.Format) {
+			continue
+		}
+
+		if ref.Version != fromVersion && ref.Version != getNameWithoutPatchLevel(fromVersion) {
+			continue
+		}
+
+		startTask("Updating %s", ref.File)
+		err = os.WriteFile(ref.File, []byte(toVersion+"\n"), 0644)
+		doneTask(err == nil)
+
+		if err != nil {
+			printWarn("Can't update version file %s: %v", ref.File, err)
+			continue
+		}
+
+		log.Info("[%s] Version in %s updated to %s", currentUser.RealName, ref.File, toVersion)
+
+		updated[ref.File] = true
+	}
+}
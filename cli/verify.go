@@ -0,0 +1,307 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/hashutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/sortutil"
+	"github.com/essentialkaos/ek/v13/terminal"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Verification exit codes (compatible with Nagios plugins)
+const (
+	VERIFY_OK       = 0 // Installed files match manifest
+	VERIFY_WARNING  = 1 // Only extra files found
+	VERIFY_CRITICAL = 2 // Modified or missing files found
+	VERIFY_UNKNOWN  = 3 // Verification can't be performed
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// verifyResult contains result of installed version verification
+type verifyResult struct {
+	Version string              `json:"version"`
+	Status  int                 `json:"status"`
+	Error   string              `json:"error,omitempty"`
+	Diff    *index.ManifestDiff `json:"diff,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// gemsManagedPaths contains paths which are changed by installing and updating
+// gems and RubyGems, so differences in them are ignored
+var gemsManagedPaths = []string{
+	"lib/ruby/gems/",
+	"lib/ruby/site_ruby/",
+	"bin/gem",
+	"bin/bundle",
+	"bin/bundler",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// verifyVersions compares installed files of given version (or all installed
+// versions) with file manifests from index
+func verifyVersions(rubyVersion string) {
+	var versions []string
+
+	if rubyVersion != "" {
+		versionName, err := getInstalledVersionName(rubyVersion)
+
+		if err != nil {
+			saveActionError(err.Error())
+			terminal.Error(err.Error())
+			exit(VERIFY_UNKNOWN)
+		}
+
+		versions = append(versions, versionName)
+	} else {
+		for versionName := range getInstalledVersionsMap() {
+			if !fsutil.IsLink(getVersionPath(versionName)) {
+				versions = append(versions, versionName)
+			}
+		}
+
+		sortutil.Versions(versions)
+	}
+
+	if len(versions) == 0 {
+		printWarn("There is no installed versions")
+		exit(VERIFY_UNKNOWN)
+	}
+
+	var results []*verifyResult
+
+	status := VERIFY_OK
+
+	for _, versionName := range versions {
+		startTask("Verifying %s", versionName)
+		result := verifyVersion(versionName)
+		doneTask(result.Status == VERIFY_OK)
+
+		results = append(results, result)
+		status = max(status, result.Status)
+
+		if result.Status != VERIFY_OK {
+			log.Error("Verification of %s failed: %s", versionName, getVerifySummary(result))
+		}
+	}
+
+	if rootResult != nil {
+		rootResult.Verify = results
+	}
+
+	fmtc.NewLine()
+
+	for _, result := range results {
+		printVerifyResult(result)
+	}
+
+	if status != VERIFY_OK {
+		saveActionError("Verification found problems with installed versions")
+	}
+
+	exit(status)
+}
+
+// verifyVersion compares installed files of given version with file manifest
+func verifyVersion(versionName string) *verifyResult {
+	result := &verifyResult{Version: versionName, Status: VERIFY_UNKNOWN}
+
+	// Manifest checksums come from index, so they can't be trusted if index
+	// signature wasn't verified
+	if repoIndexSigErr != nil && !knf.GetB(STORAGE_ALLOW_UNSIGNED, false) {
+		result.Error = fmt.Sprintf("Index signature is not verified: %v", repoIndexSigErr)
+		return result
+	}
+
+	info, _, err := getVersionInfo(versionName)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if info.Manifest == "" {
+		result.Error = "Index doesn't contain file manifest for this version"
+		return result
+	}
+
+	receipt, err := getInstallReceipt(versionName)
+
+	if err == nil && receipt.Hash != info.Hash {
+		result.Error = "Installed build differs from build in index, reinstall version to verify it"
+		return result
+	}
+
+	manifest, err := fetchManifest(info)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	actual, err := index.CreateManifest(versionName, getVersionPath(versionName))
+
+	if err != nil {
+		result.Error = fmt.Sprintf("Can't read installed files: %v", err)
+		return result
+	}
+
+	result.Diff = filterManifestDiff(getVersionPath(versionName), manifest.Diff(actual))
+
+	switch {
+	case len(result.Diff.Modified)+len(result.Diff.Missing) != 0:
+		result.Status = VERIFY_CRITICAL
+	case len(result.Diff.Extra) != 0:
+		result.Status = VERIFY_WARNING
+	default:
+		result.Status, result.Diff = VERIFY_OK, nil
+	}
+
+	return result
+}
+
+// fetchManifest fetches file manifest for given version from storage
+func fetchManifest(info *index.VersionInfo) (*index.Manifest, error) {
+	var err error
+	var data []byte
+
+	name := info.Path + "/" + info.File + index.MANIFEST_EXTENSION
+
+	for _, storageURL := range getDownloadStorageURLs() {
+		data, err = fetchStorageFile(storageURL, name)
+
+		if err != nil {
+			continue
+		}
+
+		if !hashutil.Bytes(data, sha256.New()).EqualString(info.Manifest) {
+			err = fmt.Errorf("Manifest from %s has wrong checksum", storageURL)
+			continue
+		}
+
+		return index.DecodeManifest(data)
+	}
+
+	return nil, fmt.Errorf("Can't fetch file manifest: %w", err)
+}
+
+// filterManifestDiff removes files managed by RubyGems from manifest diff
+func filterManifestDiff(versionDir string, diff *index.ManifestDiff) *index.ManifestDiff {
+	return &index.ManifestDiff{
+		Modified: filterGemsManagedPaths(versionDir, diff.Modified, false),
+		Missing:  filterGemsManagedPaths(versionDir, diff.Missing, false),
+		Extra:    filterGemsManagedPaths(versionDir, diff.Extra, true),
+	}
+}
+
+// filterGemsManagedPaths removes paths managed by RubyGems from given slice
+func filterGemsManagedPaths(versionDir string, files []string, extra bool) []string {
+	var result []string
+
+MAIN:
+	for _, file := range files {
+		// Executables installed by gems (binstubs are rewritten on gem update)
+		if strings.HasPrefix(file, "bin/") && (extra || isGemBinstub(path.Join(versionDir, file))) {
+			continue
+		}
+
+		for _, managedPath := range gemsManagedPaths {
+			if file == managedPath || (strings.HasSuffix(managedPath, "/") && strings.HasPrefix(file, managedPath)) {
+				continue MAIN
+			}
+		}
+
+		result = append(result, file)
+	}
+
+	return result
+}
+
+// isGemBinstub returns true if given file is executable wrapper generated
+// by RubyGems
+func isGemBinstub(file string) bool {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return false
+	}
+
+	defer fd.Close()
+
+	// Marker is always placed in the header of wrapper
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(fd, buf)
+
+	return bytes.Contains(buf[:n], []byte("This file was generated by RubyGems"))
+}
+
+// printVerifyResult prints result of version verification
+func printVerifyResult(result *verifyResult) {
+	switch result.Status {
+	case VERIFY_OK:
+		fmtc.Printfn("{g}✔  {!}{*}%s{!} {s}—{!} {g}OK{!}", result.Version)
+		return
+	case VERIFY_UNKNOWN:
+		fmtc.Printfn("{s}?  {!}{*}%s{!} {s}—{!} {s}%s{!}", result.Version, result.Error)
+		return
+	case VERIFY_WARNING:
+		fmtc.Printfn("{y}✖  {!}{*}%s{!} {s}—{!} {y}%s{!}", result.Version, getVerifySummary(result))
+	default:
+		fmtc.Printfn("{r}✖  {!}{*}%s{!} {s}—{!} {r}%s{!}", result.Version, getVerifySummary(result))
+	}
+
+	fmtutil.Separator(true)
+
+	printVerifyList("Modified", result.Diff.Modified, "{r}")
+	printVerifyList("Missing", result.Diff.Missing, "{r}")
+	printVerifyList("Extra", result.Diff.Extra, "{y}")
+
+	fmtutil.Separator(true)
+}
+
+// printVerifyList prints list of files in verification result
+func printVerifyList(name string, files []string, colorTag string) {
+	for index, file := range files {
+		if index != 0 {
+			name = ""
+		}
+
+		fmtc.Printfn(" {*}%-10s{!} {s}|{!} "+colorTag+"%s{!}", name, file)
+	}
+}
+
+// getVerifySummary returns short summary of verification result
+func getVerifySummary(result *verifyResult) string {
+	if result.Diff == nil {
+		return result.Error
+	}
+
+	return fmt.Sprintf(
+		"%d modified, %d missing, %d extra",
+		len(result.Diff.Modified), len(result.Diff.Missing), len(result.Diff.Extra),
+	)
+}
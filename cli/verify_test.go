@@ -0,0 +1,35 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestVerifyWithUnverifiedIndex(t *testing.T) {
+	rbenvDir := setupTestRBEnv(t, "\n[storage]\n  allow-unsigned: false\n")
+
+	addTestVersion(t, rbenvDir, "3.3.0", true)
+
+	repoIndexSigErr = errors.New("signature is invalid")
+	defer func() { repoIndexSigErr = nil }()
+
+	result := verifyVersion("3.3.0")
+
+	if result.Status != VERIFY_UNKNOWN {
+		t.Errorf("Verification status is %d, want %d", result.Status, VERIFY_UNKNOWN)
+	}
+
+	if !strings.HasPrefix(result.Error, "Index signature is not verified") {
+		t.Errorf("Verification returned unexpected error %q", result.Error)
+	}
+}
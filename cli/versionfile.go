@@ -0,0 +1,226 @@
+package cli
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// versionFileReader is function for reading version from file
+type versionFileReader func(file string) (string, error)
+
+// versionFileFormat contains info about version file format
+type versionFileFormat struct {
+	Name   string
+	Reader versionFileReader
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// versionFileFormats is slice with supported version files ordered by priority
+var versionFileFormats = []versionFileFormat{
+	{".ruby-version", readRubyVersionFile},
+	{".rbenv-version", readRubyVersionFile},
+	{".tool-versions", readToolVersionsFile},
+	{"Gemfile.lock", readGemfileLock},
+	{"Gemfile", readGemfile},
+}
+
+// gemfileRubyRegex is regex for ruby directive in Gemfile
+var gemfileRubyRegex = regexp.MustCompile(`^\s*ruby\s*\(?\s*(file:\s*)?["']([^"']+)["']`)
+
+// lockRubyRegex is regex for version in RUBY VERSION section of Gemfile.lock
+var lockRubyRegex = regexp.MustCompile(`^\s*ruby\s+([0-9.]+)(?:p([0-9]+))?(?:\s+\((\w+)\s+([0-9.]+)\))?`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getVersionFromFile tries to find version file in current or parent directories
+// and returns defined version and path to the file
+func getVersionFromFile() (string, string, error) {
+	dir, err := os.Getwd()
+
+	if err != nil {
+		return "", "", fmtc.Errorf("Can't get current directory: %v", err)
+	}
+
+	for {
+		for _, format := range versionFileFormats {
+			versionFile := path.Join(dir, format.Name)
+
+			if !fsutil.CheckPerms("FRS", versionFile) {
+				continue
+			}
+
+			versionName, err := format.Reader(versionFile)
+
+			if err != nil {
+				return "", versionFile, fmtc.Errorf("Can't use version file %s: %v", versionFile, err)
+			}
+
+			// Gemfile without ruby directive doesn't define version
+			if versionName == "" {
+				continue
+			}
+
+			return normalizeVersionName(versionName), versionFile, nil
+		}
+
+		if dir == "/" {
+			break
+		}
+
+		dir = path.Dir(dir)
+	}
+
+	return "", "", fmtc.Errorf("Can't find proper version file")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readRubyVersionFile reads version from .ruby-version file
+func readRubyVersionFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	versionName := strings.Trim(string(data), " \t\n\r")
+
+	if versionName == "" || strings.ContainsAny(versionName, "\n\r") {
+		return "", fmtc.Errorf("file malformed")
+	}
+
+	return versionName, nil
+}
+
+// readToolVersionsFile reads version from asdf .tool-versions file
+func readToolVersionsFile(file string) (string, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+
+		// The first version is used if there are several versions defined
+		if len(fields) >= 2 && fields[0] == "ruby" {
+			return fields[1], nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// readGemfile reads version from ruby directive in Gemfile
+func readGemfile(file string) (string, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+
+	for scanner.Scan() {
+		m := gemfileRubyRegex.FindStringSubmatch(scanner.Text())
+
+		if m == nil {
+			continue
+		}
+
+		// ruby file: ".ruby-version" or ruby file: ".tool-versions"
+		if m[1] != "" {
+			versionFile := path.Join(path.Dir(file), m[2])
+
+			if path.Base(versionFile) == ".tool-versions" {
+				return readToolVersionsFile(versionFile)
+			}
+
+			return readRubyVersionFile(versionFile)
+		}
+
+		return m[2], nil
+	}
+
+	return "", scanner.Err()
+}
+
+// readGemfileLock reads version from RUBY VERSION section of Gemfile.lock
+func readGemfileLock(file string) (string, error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer fd.Close()
+
+	var isRubySection bool
+
+	scanner := bufio.NewScanner(fd)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !isRubySection {
+			isRubySection = line == "RUBY VERSION"
+			continue
+		}
+
+		m := lockRubyRegex.FindStringSubmatch(line)
+
+		if m == nil {
+			return "", fmtc.Errorf("RUBY VERSION section is malformed")
+		}
+
+		// ruby 3.1.4p0 (jruby 9.4.5.0)
+		if m[3] != "" {
+			return m[3] + "-" + m[4], nil
+		}
+
+		// Patch level is a part of version name only for versions older than 2.1
+		if m[2] != "" && m[2] != "0" && sortutil.VersionCompare(m[1], "2.1") {
+			return m[1] + "-p" + m[2], nil
+		}
+
+		return m[1], nil
+	}
+
+	return "", scanner.Err()
+}
+
+// isExactVersionFile returns true if version file always contains exact
+// version name
+func isExactVersionFile(file string) bool {
+	return path.Base(file) == "Gemfile.lock"
+}
+
+// normalizeVersionName removes prefixes used by other version managers
+func normalizeVersionName(versionName string) string {
+	return strings.TrimPrefix(versionName, "ruby-")
+}
@@ -9,15 +9,20 @@ package clone
 
 import (
 	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/fmtutil"
 	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/hashutil"
 	"github.com/essentialkaos/ek/v13/httputil"
 	"github.com/essentialkaos/ek/v13/jsonutil"
 	"github.com/essentialkaos/ek/v13/options"
@@ -38,6 +43,7 @@ import (
 	"github.com/essentialkaos/ek/v13/usage/man"
 
 	"github.com/essentialkaos/rbinstall/index"
+	"github.com/essentialkaos/rbinstall/sign"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -51,10 +57,23 @@ const (
 
 // Options
 const (
-	OPT_YES      = "y:yes"
-	OPT_NO_COLOR = "nc:no-color"
-	OPT_HELP     = "h:help"
-	OPT_VER      = "v:version"
+	OPT_YES         = "y:yes"
+	OPT_PRUNE       = "P:prune"
+	OPT_WORKERS     = "w:workers"
+	OPT_LIMIT_RATE  = "R:limit-rate"
+	OPT_DIST        = "D:dist"
+	OPT_ARCH        = "A:arch"
+	OPT_CATEGORY    = "C:category"
+	OPT_NAME        = "N:name"
+	OPT_NO_EOL      = "E:no-eol"
+	OPT_KEY         = "k:key"
+	OPT_WATCH       = "W:watch"
+	OPT_STATUS_FILE = "status-file"
+	OPT_SERVE       = "S:serve"
+	OPT_ACCESS_LOG  = "L:access-log"
+	OPT_NO_COLOR    = "nc:no-color"
+	OPT_HELP        = "h:help"
+	OPT_VER         = "v:version"
 
 	OPT_VERB_VER     = "vv:verbose-version"
 	OPT_COMPLETION   = "completion"
@@ -66,6 +85,9 @@ const (
 // INDEX_NAME is name of index file
 const INDEX_NAME = "index3.json"
 
+// ARCHIVE_EXTENSION is extension of archives with Ruby data
+const ARCHIVE_EXTENSION = ".tzst"
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // FileInfo contains info about file with Ruby data
@@ -74,16 +96,52 @@ type FileInfo struct {
 	URL  string
 	OS   string
 	Arch string
+	Hash string
 	Size int64
 }
 
+// downloadTask contains info about file which must be downloaded
+type downloadTask struct {
+	Item    FileInfo
+	Output  string
+	IsExist bool
+}
+
+// progressWriter is writer which updates progress bar
+type progressWriter struct {
+	pb      *progress.Bar
+	written int64
+}
+
+// cloneStats contains info about changes made in cloned repository
+type cloneStats struct {
+	Added      int
+	Updated    int
+	Removed    int
+	Unchanged  int
+	Downloaded int64
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 var optMap = options.Map{
-	OPT_YES:      {Type: options.BOOL},
-	OPT_NO_COLOR: {Type: options.BOOL},
-	OPT_HELP:     {Type: options.BOOL},
-	OPT_VER:      {Type: options.MIXED},
+	OPT_YES:         {Type: options.BOOL},
+	OPT_PRUNE:       {Type: options.BOOL},
+	OPT_WORKERS:     {Type: options.INT, Value: 1, Min: 1, Max: 32},
+	OPT_LIMIT_RATE:  {},
+	OPT_DIST:        {},
+	OPT_ARCH:        {},
+	OPT_CATEGORY:    {},
+	OPT_NAME:        {},
+	OPT_NO_EOL:      {Type: options.BOOL},
+	OPT_KEY:         {},
+	OPT_WATCH:       {},
+	OPT_STATUS_FILE: {Bound: OPT_WATCH},
+	OPT_SERVE:       {},
+	OPT_ACCESS_LOG:  {Bound: OPT_SERVE},
+	OPT_NO_COLOR:    {Type: options.BOOL},
+	OPT_HELP:        {Type: options.BOOL},
+	OPT_VER:         {Type: options.MIXED},
 
 	OPT_VERB_VER:     {Type: options.BOOL},
 	OPT_COMPLETION:   {},
@@ -92,6 +150,15 @@ var optMap = options.Map{
 
 var colorTagApp, colorTagVer string
 
+// filter is repository data filter
+var filter *repoFilter
+
+// signKey is private key used for signing filtered index
+var signKey ed25519.PrivateKey
+
+// watchInterval is interval between index checks in watch mode
+var watchInterval time.Duration
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 func Run(gitRev string, gomod []byte) {
@@ -122,6 +189,11 @@ func Run(gitRev string, gomod []byte) {
 		support.Collect(APP, VER).WithRevision(gitRev).
 			WithDeps(deps.Extract(gomod)).Print()
 		os.Exit(0)
+	case options.Has(OPT_SERVE) && len(args) == 1:
+		runtime.GOMAXPROCS(runtime.NumCPU())
+		checkDir(args.Get(0).Clean().String())
+		serveRepository(options.GetS(OPT_SERVE), args.Get(0).Clean().String())
+		os.Exit(0)
 	case options.GetB(OPT_HELP) || len(args) != 2:
 		genUsage().Print()
 		os.Exit(0)
@@ -133,7 +205,12 @@ func Run(gitRev string, gomod []byte) {
 	fmtc.NewLine()
 
 	checkArguments(url, dir)
-	cloneRepository(url, dir)
+
+	if options.Has(OPT_WATCH) {
+		watchRepository(url, dir, watchInterval)
+	} else {
+		cloneRepository(url, dir)
+	}
 
 	fmtc.NewLine()
 }
@@ -173,6 +250,43 @@ func checkArguments(url, dir string) {
 		printErrorAndExit("Url %s doesn't look like valid url", url)
 	}
 
+	if options.Has(OPT_LIMIT_RATE) && fmtutil.ParseSize(options.GetS(OPT_LIMIT_RATE)) == 0 {
+		printErrorAndExit("Can't parse download speed limit %q", options.GetS(OPT_LIMIT_RATE))
+	}
+
+	var err error
+
+	filter, err = getRepoFilter()
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	if options.Has(OPT_KEY) {
+		signKey, err = sign.ReadPrivateKey(options.GetS(OPT_KEY))
+
+		if err != nil {
+			printErrorAndExit(err.Error())
+		}
+	}
+
+	if options.Has(OPT_WATCH) {
+		watchInterval, err = timeutil.ParseDuration(options.GetS(OPT_WATCH), 's')
+
+		if err != nil {
+			printErrorAndExit("Can't parse watch interval %q: %v", options.GetS(OPT_WATCH), err)
+		}
+
+		if watchInterval < MIN_WATCH_INTERVAL {
+			printErrorAndExit("Watch interval must be at least %s", timeutil.PrettyDuration(MIN_WATCH_INTERVAL))
+		}
+	}
+
+	checkDir(dir)
+}
+
+// checkDir checks repository directory
+func checkDir(dir string) {
 	if !fsutil.IsExist(dir) {
 		printErrorAndExit("Directory %s does not exist", dir)
 	}
@@ -194,26 +308,19 @@ func checkArguments(url, dir string) {
 func cloneRepository(url, dir string) {
 	fmtc.Printfn("Fetching index from {*}%s{!}…", url)
 
-	i, err := fetchIndex(url)
+	i, indexData, indexSig, err := fetchRepositoryIndex(url, nil)
 
 	if err != nil {
 		printErrorAndExit(err.Error())
 	}
 
-	if i.Meta.Items == 0 {
-		printErrorAndExit("Repository is empty")
-	}
-
 	printRepositoryInfo(i)
 
-	uuid := getCurrentIndexUUID(dir)
-
-	if uuid == i.UUID {
-		fmtc.Println("{g}Looks like you already have the same set of data{!}")
-		return
-	}
-
-	if !options.GetB(OPT_YES) {
+	// Even if index is the same, local files still must be verified because
+	// they can be corrupted or removed
+	if isSameData(i, dir) {
+		fmtc.Println("{g}Looks like you already have the same set of data, verifying it…{!}\n")
+	} else if !options.GetB(OPT_YES) {
 		ok, err := input.ReadAnswer("Clone this repository?", "N")
 
 		if err != nil || !ok {
@@ -221,13 +328,103 @@ func cloneRepository(url, dir string) {
 		}
 	}
 
-	downloadRepositoryData(i, url, dir)
-	saveIndex(i, dir)
+	stats, err := syncRepository(i, indexData, indexSig, url, dir)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	printCloneSummary(stats)
 
 	fmtc.NewLine()
 	fmtc.Printfn("{g}Repository successfully cloned to {g*}%s{!}", dir)
 }
 
+// fetchRepositoryIndex fetches remote index with its signature and applies
+// filters to it
+func fetchRepositoryIndex(url string, validators *indexValidators) (*index.Index, []byte, string, error) {
+	i, indexData, err := fetchIndex(url, validators)
+
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	indexSig, err := fetchIndexSignature(url)
+
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if i.Meta.Items == 0 {
+		return nil, nil, "", fmtc.Errorf("Repository is empty")
+	}
+
+	if filter == nil {
+		return i, indexData, indexSig, nil
+	}
+
+	i = filter.Apply(i)
+
+	if i.Meta.Items == 0 {
+		return nil, nil, "", fmtc.Errorf("There is no data in repository matching given filters")
+	}
+
+	indexData, indexSig, err = encodeFilteredIndex(i)
+
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return i, indexData, indexSig, nil
+}
+
+// syncRepository downloads repository data, saves index and removes outdated
+// files (if required)
+func syncRepository(i *index.Index, indexData []byte, indexSig, url, dir string) (*cloneStats, error) {
+	stats, err := downloadRepositoryData(i, url, dir)
+
+	if err != nil {
+		return stats, err
+	}
+
+	err = saveIndex(indexData, indexSig, dir)
+
+	if err != nil {
+		return stats, err
+	}
+
+	if options.GetB(OPT_PRUNE) {
+		stats.Removed, err = pruneRepositoryData(i, dir)
+	}
+
+	return stats, err
+}
+
+// encodeFilteredIndex encodes filtered index and signs it with private key
+// (if set)
+func encodeFilteredIndex(i *index.Index) ([]byte, string, error) {
+	i.Sort()
+
+	// Index.Encode is not used because it updates index creation date
+	indexData, err := json.MarshalIndent(i, "", "  ")
+
+	if err != nil {
+		return nil, "", fmtc.Errorf("Can't encode filtered index: %v", err)
+	}
+
+	if signKey != nil {
+		return indexData, sign.Sign(indexData, signKey), nil
+	}
+
+	// Signature of original index is not valid for filtered index
+	printWarn(
+		"Filtered index is not signed, because signature of original index can't be used for it. " +
+			"Use --key option for signing it with your own key, or allow unsigned index on clients.",
+	)
+
+	return indexData, "", nil
+}
+
 // printRepositoryInfo prints basic info about repository data
 func printRepositoryInfo(i *index.Index) {
 	fmtutil.Separator(false, "REPOSITORY INFO")
@@ -271,31 +468,112 @@ func printRepositoryInfo(i *index.Index) {
 	fmtutil.Separator(false)
 }
 
-// fetchIndex downloads remote repository index
-func fetchIndex(url string) (*index.Index, error) {
-	resp, err := req.Request{URL: url + "/" + INDEX_NAME}.Get()
+// fetchIndex downloads remote repository index and returns it with raw index data
+func fetchIndex(url string, validators *indexValidators) (*index.Index, []byte, error) {
+	r := req.Request{URL: url + "/" + INDEX_NAME}
+
+	if validators != nil {
+		r.Headers = validators.Headers()
+	}
+
+	resp, err := r.Get()
 
 	if err != nil {
-		return nil, fmtc.Errorf("Can't fetch repository index: %v", err)
+		return nil, nil, fmtc.Errorf("Can't fetch repository index: %v", err)
+	}
+
+	if resp.StatusCode == 304 && validators != nil {
+		resp.Discard()
+		return nil, nil, errNotModified
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmtc.Errorf("Can't fetch repository index: server return status code %d", resp.StatusCode)
+		resp.Discard()
+		return nil, nil, fmtc.Errorf("Can't fetch repository index: server return status code %d", resp.StatusCode)
+	}
+
+	data, err := resp.Bytes()
+
+	if err != nil {
+		return nil, nil, fmtc.Errorf("Can't read repository index: %v", err)
 	}
 
 	repoIndex := &index.Index{}
-	err = resp.JSON(repoIndex)
+	err = json.Unmarshal(data, repoIndex)
 
 	if err != nil {
-		return nil, fmtc.Errorf("Can't decode repository index: %v", err)
+		return nil, nil, fmtc.Errorf("Can't decode repository index: %v", err)
+	}
+
+	if validators != nil {
+		validators.ETag = resp.Header.Get("ETag")
+		validators.LastModified = resp.Header.Get("Last-Modified")
 	}
 
-	return repoIndex, nil
+	return repoIndex, data, nil
 }
 
-// downloadRepositoryData downloads all files from repository
-func downloadRepositoryData(i *index.Index, url, dir string) {
+// fetchIndexSignature downloads remote repository index signature (if exist)
+func fetchIndexSignature(url string) (string, error) {
+	resp, err := req.Request{URL: url + "/" + INDEX_NAME + sign.EXTENSION}.Get()
+
+	if err != nil {
+		return "", fmtc.Errorf("Can't fetch repository index signature: %v", err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		// continue
+	case 404:
+		resp.Discard()
+		return "", nil
+	default:
+		resp.Discard()
+		return "", fmtc.Errorf("Can't fetch repository index signature: server return status code %d", resp.StatusCode)
+	}
+
+	return resp.String(), nil
+}
+
+// downloadRepositoryData downloads all new or changed files from repository
+func downloadRepositoryData(i *index.Index, url, dir string) (*cloneStats, error) {
 	items := getItems(i, url)
+	stats := &cloneStats{}
+	tasks, err := checkRepositoryData(items, dir, stats)
+
+	if err != nil || len(tasks) == 0 {
+		return stats, err
+	}
+
+	errs := fetchRepositoryData(tasks, stats)
+
+	if len(errs) != 0 {
+		fmtc.NewLine()
+
+		for _, err := range errs {
+			terminal.Error(" - %v", err)
+		}
+
+		fmtc.NewLine()
+
+		return stats, fmtc.Errorf(
+			"Can't download %s %s",
+			fmtutil.PrettyNum(len(errs)),
+			pluralize.Pluralize(len(errs), "file", "files"),
+		)
+	}
+
+	if interrupted.Load() {
+		return stats, errInterrupted
+	}
+
+	return stats, nil
+}
+
+// checkRepositoryData checks local copies of repository files and returns
+// slice with files which must be downloaded
+func checkRepositoryData(items []FileInfo, dir string, stats *cloneStats) ([]*downloadTask, error) {
+	var tasks []*downloadTask
 
 	pb := progress.New(int64(len(items)), "Starting…")
 
@@ -313,7 +591,7 @@ func downloadRepositoryData(i *index.Index, url, dir string) {
 	pb.Start()
 
 	fmtc.Printfn(
-		"Downloading %s %s from remote repository…",
+		"Checking %s %s…",
 		fmtutil.PrettyNum(len(items)),
 		pluralize.Pluralize(len(items), "file", "files"),
 	)
@@ -328,33 +606,158 @@ func downloadRepositoryData(i *index.Index, url, dir string) {
 			if err != nil {
 				pb.Finish()
 				fmtc.NewLine()
-				printErrorAndExit("Can't create directory %s: %v", fileDir, err)
+				return nil, fmtc.Errorf("Can't create directory %s: %v", fileDir, err)
 			}
 		}
 
-		if fsutil.IsExist(filePath) {
-			fileSize := fsutil.GetSize(filePath)
+		isExist := fsutil.IsExist(filePath)
+
+		if isExist && isFileValid(item, filePath) {
+			stats.Unchanged++
+		} else {
+			tasks = append(tasks, &downloadTask{item, filePath, isExist})
+		}
+
+		pb.Add(1)
+	}
+
+	pb.Finish()
+
+	return tasks, nil
+}
+
+// fetchRepositoryData downloads given files using pool of workers and returns
+// slice with download errors
+func fetchRepositoryData(tasks []*downloadTask, stats *cloneStats) []error {
+	var totalSize int64
+
+	for _, task := range tasks {
+		totalSize += task.Item.Size
+	}
+
+	workers := min(options.GetI(OPT_WORKERS), len(tasks))
+	limiter := newRateLimiter(int64(fmtutil.ParseSize(options.GetS(OPT_LIMIT_RATE))))
+
+	pb := progress.New(totalSize, fmt.Sprintf("0/%d", len(tasks)))
+
+	pbs := progress.DefaultSettings
+	pbs.NameColorTag = "{*}"
+	pbs.BarFgColorTag = colorTagApp
+	pbs.PercentColorTag = ""
+	pbs.ProgressColorTag = "{s}"
+	pbs.SpeedColorTag = "{s}"
+	pbs.RemainingColorTag = "{s}"
+
+	pb.UpdateSettings(pbs)
+
+	fmtc.NewLine()
+	fmtc.Printfn(
+		"Downloading %s %s {s-}(%s){!} from remote repository using %s %s…",
+		fmtutil.PrettyNum(len(tasks)),
+		pluralize.Pluralize(len(tasks), "file", "files"),
+		fmtutil.PrettySize(totalSize, " "),
+		fmtutil.PrettyNum(workers),
+		pluralize.Pluralize(workers, "worker", "workers"),
+	)
+
+	pb.Start()
+
+	var errs []error
+	var done int
+	var mx sync.Mutex
+	var wg sync.WaitGroup
+
+	queue := make(chan *downloadTask)
+
+	for range workers {
+		wg.Add(1)
 
-			if fileSize == item.Size {
-				pb.Add(1)
-				continue
+		go func() {
+			defer wg.Done()
+
+			for task := range queue {
+				size, err := downloadFile(task.Item, task.Output, pb, limiter)
+
+				mx.Lock()
+
+				switch {
+				case err != nil:
+					errs = append(errs, err)
+				case task.IsExist:
+					stats.Updated++
+				default:
+					stats.Added++
+				}
+
+				done++
+				stats.Downloaded += size
+				pb.SetName(fmt.Sprintf("%d/%d", done, len(tasks)))
+
+				mx.Unlock()
 			}
+		}()
+	}
+
+	for _, task := range tasks {
+		// Downloads which are already started will be finished
+		if interrupted.Load() {
+			break
 		}
 
-		err := downloadFile(item.URL, filePath)
+		queue <- task
+	}
+
+	close(queue)
+	wg.Wait()
+
+	pb.Finish()
+
+	return errs
+}
+
+// pruneRepositoryData removes archives and manifests which are not present in
+// repository index
+func pruneRepositoryData(i *index.Index, dir string) (int, error) {
+	knownFiles := make(map[string]bool)
+
+	for _, item := range getItems(i, "") {
+		knownFiles[path.Join(item.OS, item.Arch, item.File)] = true
+	}
+
+	files := fsutil.ListAllFiles(dir, true, fsutil.ListingFilter{
+		MatchPatterns: []string{"*" + ARCHIVE_EXTENSION, "*" + ARCHIVE_EXTENSION + index.MANIFEST_EXTENSION},
+	})
+
+	var removed int
+
+	for _, file := range files {
+		if knownFiles[file] {
+			continue
+		}
+
+		err := os.Remove(path.Join(dir, file))
 
 		if err != nil {
-			pb.Finish()
-			fmtc.NewLine()
-			printErrorAndExit("%v", err)
+			return removed, fmtc.Errorf("Can't remove file %s: %v", path.Join(dir, file), err)
 		}
 
-		pb.Add(1)
+		fmtc.Printfn("{s-}Removed %s{!}", file)
+
+		removed++
 	}
 
-	pb.Finish()
+	return removed, nil
+}
 
-	fmtc.Printfn("\n{g}Repository successfully cloned into %s{!}")
+// printCloneSummary prints summary with info about changes in cloned repository
+func printCloneSummary(stats *cloneStats) {
+	fmtc.NewLine()
+	fmtc.Printfn(
+		"  {*}Added:{!} %s {s-}|{!} {*}Updated:{!} %s {s-}|{!} {*}Removed:{!} %s {s-}|{!} {*}Unchanged:{!} %s",
+		fmtutil.PrettyNum(stats.Added), fmtutil.PrettyNum(stats.Updated),
+		fmtutil.PrettyNum(stats.Removed), fmtutil.PrettyNum(stats.Unchanged),
+	)
+	fmtc.Printfn("  {*}Downloaded:{!} %s", fmtutil.PrettySize(stats.Downloaded, " "))
 }
 
 // getItems returns slice with info about items in repository
@@ -365,23 +768,11 @@ func getItems(repoIndex *index.Index, url string) []FileInfo {
 		for _, arch := range repoIndex.Data[os].Keys() {
 			for _, category := range repoIndex.Data[os][arch].Keys() {
 				for _, version := range repoIndex.Data[os][arch][category] {
-					items = append(items, FileInfo{
-						File: version.File,
-						URL:  url + "/" + version.Path + "/" + version.File,
-						OS:   os,
-						Arch: arch,
-						Size: version.Size,
-					})
+					items = append(items, getVersionItems(version, url, os, arch)...)
 
 					if len(version.Variations) != 0 {
 						for _, subVersion := range version.Variations {
-							items = append(items, FileInfo{
-								File: subVersion.File,
-								URL:  url + "/" + subVersion.Path + "/" + subVersion.File,
-								OS:   os,
-								Arch: arch,
-								Size: subVersion.Size,
-							})
+							items = append(items, getVersionItems(subVersion, url, os, arch)...)
 						}
 					}
 				}
@@ -392,73 +783,191 @@ func getItems(repoIndex *index.Index, url string) []FileInfo {
 	return items
 }
 
-// downloadFile downloads and saves remote file
-func downloadFile(url, output string) error {
-	if fsutil.IsExist(output) {
-		os.Remove(output)
+// getVersionItems returns info about archive and file manifest for given version
+func getVersionItems(version *index.VersionInfo, url, os, arch string) []FileInfo {
+	items := []FileInfo{{
+		File: version.File,
+		URL:  url + "/" + version.Path + "/" + version.File,
+		OS:   os,
+		Arch: arch,
+		Hash: version.Hash,
+		Size: version.Size,
+	}}
+
+	if version.Manifest != "" {
+		items = append(items, FileInfo{
+			File: version.File + index.MANIFEST_EXTENSION,
+			URL:  url + "/" + version.Path + "/" + version.File + index.MANIFEST_EXTENSION,
+			OS:   os,
+			Arch: arch,
+			Hash: version.Manifest,
+		})
 	}
 
-	fd, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY, 0644)
+	return items
+}
+
+// isFileValid returns true if local file has the same checksum as file
+// in repository
+func isFileValid(item FileInfo, filePath string) bool {
+	if item.Hash == "" {
+		return fsutil.GetSize(filePath) == item.Size
+	}
+
+	return hashutil.File(filePath, sha256.New()).EqualString(item.Hash)
+}
+
+// downloadFile downloads remote file, verifies its checksum and saves it
+func downloadFile(item FileInfo, output string, pb *progress.Bar, limiter *rateLimiter) (int64, error) {
+	tmpFile := getTempFilePath(output)
+	fd, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 
 	if err != nil {
-		return fmtc.Errorf("Can't create file: %v", err)
+		return 0, fmtc.Errorf("Can't create file %s: %v", tmpFile, err)
+	}
+
+	pw := &progressWriter{pb: pb}
+
+	// Size of manifests is unknown, so they are not counted in progress
+	if item.Size == 0 {
+		pw.pb = nil
 	}
 
-	defer fd.Close()
+	size, err := writeRemoteFile(item, fd, pw, limiter)
 
-	resp, err := req.Request{URL: url}.Get()
+	fd.Close()
+
+	if err == nil {
+		err = os.Rename(tmpFile, output)
+
+		if err != nil {
+			err = fmtc.Errorf("Can't save file %s: %v", output, err)
+		}
+	}
 
 	if err != nil {
-		return fmtc.Errorf("Can't download file: %v", err)
+		// Roll back progress made by failed download (progress of manifests
+		// is not counted, so there is nothing to roll back for them)
+		if pw.pb != nil {
+			pw.pb.Add64(-pw.written)
+		}
+		os.Remove(tmpFile)
+		return 0, err
 	}
 
+	return size, nil
+}
+
+// writeRemoteFile downloads remote file and writes its data into given file
+func writeRemoteFile(item FileInfo, fd *os.File, pw *progressWriter, limiter *rateLimiter) (int64, error) {
+	resp, err := req.Request{URL: item.URL}.Get()
+
+	if err != nil {
+		return 0, fmtc.Errorf("Can't download file %s: %v", item.URL, err)
+	}
+
+	defer resp.Body.Close()
+
 	if resp.StatusCode != 200 {
-		return fmtc.Errorf("Can't download file: server return status code %d", resp.StatusCode)
+		return 0, fmtc.Errorf("Can't download file %s: server return status code %d", item.URL, resp.StatusCode)
 	}
 
+	hasher := sha256.New()
 	w := bufio.NewWriter(fd)
-	_, err = io.Copy(w, resp.Body)
+	size, err := io.Copy(io.MultiWriter(w, hasher, pw), limiter.Reader(resp.Body))
 
-	w.Flush()
+	if err == nil {
+		err = w.Flush()
+	}
 
 	if err != nil {
-		return fmtc.Errorf("Can't write file: %v", err)
+		return 0, fmtc.Errorf("Can't write file %s: %v", fd.Name(), err)
 	}
 
-	return nil
+	if item.Hash != "" && !hashutil.Hash(hasher.Sum(nil)).EqualString(item.Hash) {
+		return 0, fmtc.Errorf("Can't download file %s: checksum mismatch", item.URL)
+	}
+
+	return size, nil
 }
 
-// saveIndex encodes index to JSON format and saves it into the file
-func saveIndex(repoIndex *index.Index, dir string) {
+// saveIndex saves original index data and its signature into the files
+func saveIndex(indexData []byte, indexSig, dir string) error {
 	indexPath := path.Join(dir, INDEX_NAME)
+	sigPath := indexPath + sign.EXTENSION
 
 	fmtc.Printf("Saving index… ")
 
-	err := jsonutil.Write(indexPath, repoIndex)
+	err := writeFile(indexPath, indexData)
 
 	if err != nil {
 		fmtc.Println("{r}ERROR{!}")
-		printErrorAndExit("Can't save index as %s: %v", indexPath, err)
+		return fmtc.Errorf("Can't save index as %s: %v", indexPath, err)
+	}
+
+	if indexSig == "" {
+		os.Remove(sigPath)
+	} else {
+		err = writeFile(sigPath, []byte(indexSig))
+
+		if err != nil {
+			fmtc.Println("{r}ERROR{!}")
+			return fmtc.Errorf("Can't save index signature as %s: %v", sigPath, err)
+		}
 	}
 
 	fmtc.Println("{g}DONE{!}")
+
+	return nil
+}
+
+// writeFile writes data to temporary file and then renames it
+func writeFile(file string, data []byte) error {
+	tmpFile := getTempFilePath(file)
+	err := os.WriteFile(tmpFile, data, 0644)
+
+	if err == nil {
+		err = os.Rename(tmpFile, file)
+	}
+
+	if err != nil {
+		os.Remove(tmpFile)
+	}
+
+	return err
+}
+
+// Write updates progress bar with size of written data
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pb.Add(len(p))
+	w.written += int64(len(p))
+	return len(p), nil
+}
+
+// getTempFilePath returns path to hidden temporary file used for writing data
+func getTempFilePath(file string) string {
+	return path.Join(path.Dir(file), "."+path.Base(file)+".part")
 }
 
-// getCurrentIndexUUID returns current index UUID (if exist)
-func getCurrentIndexUUID(dir string) string {
+// isSameData returns true if local copy of repository contains the same set of data
+func isSameData(i *index.Index, dir string) bool {
 	indexFile := path.Join(dir, INDEX_NAME)
 
 	if !fsutil.IsExist(indexFile) {
-		return ""
+		return false
 	}
 
-	i := &index.Index{}
+	localIndex := &index.Index{}
 
-	if jsonutil.Read(indexFile, i) != nil {
-		return ""
+	if jsonutil.Read(indexFile, localIndex) != nil || localIndex.Meta == nil {
+		return false
 	}
 
-	return i.UUID
+	// Filtered index has the same UUID as original, so we also have to compare
+	// metadata in case if filters were changed
+	return localIndex.UUID == i.UUID &&
+		localIndex.Meta.Items == i.Meta.Items &&
+		localIndex.Meta.Size == i.Meta.Size
 }
 
 // printErrorAndExit print error message and exit with non-zero exit code
@@ -468,6 +977,12 @@ func printErrorAndExit(f string, a ...any) {
 	os.Exit(1)
 }
 
+// printWarn prints warning message
+func printWarn(f string, a ...any) {
+	terminal.Warn(f, a...)
+	fmtc.NewLine()
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // printCompletion prints completion for given shell
@@ -500,6 +1015,19 @@ func genUsage() *usage.Info {
 	info.AppNameColorTag = colorTagApp
 
 	info.AddOption(OPT_YES, `Answer "yes" to all questions`)
+	info.AddOption(OPT_PRUNE, "Remove files which are not present in remote repository")
+	info.AddOption(OPT_WORKERS, "Number of parallel downloads {s-}(1-32, default: 1){!}", "num")
+	info.AddOption(OPT_LIMIT_RATE, "Total download speed limit in bytes per second {s-}(e.g. 512K, 10M){!}", "speed")
+	info.AddOption(OPT_DIST, "Clone only data for given dists {s-}(comma-separated){!}", "dist")
+	info.AddOption(OPT_ARCH, "Clone only data for given architectures {s-}(comma-separated){!}", "arch")
+	info.AddOption(OPT_CATEGORY, "Clone only data from given categories {s-}(comma-separated){!}", "category")
+	info.AddOption(OPT_NAME, "Clone only versions with names matching given globs {s-}(comma-separated){!}", "glob")
+	info.AddOption(OPT_NO_EOL, "Don't clone EOL versions")
+	info.AddOption(OPT_KEY, "Private key for signing filtered index", "file")
+	info.AddOption(OPT_WATCH, "Check remote repository for changes with given interval and sync data {s-}(e.g. 30m, 1h){!}", "interval")
+	info.AddOption(OPT_STATUS_FILE, "Path to file with sync status {s-}(default: <path>/"+STATUS_NAME+"){!}", "file")
+	info.AddOption(OPT_SERVE, "Serve cloned repository over HTTP", "address")
+	info.AddOption(OPT_ACCESS_LOG, "Path to access log file {s-}(default: stdout){!}", "file")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VER, "Show version")
@@ -509,6 +1037,31 @@ func genUsage() *usage.Info {
 		"Clone EK repository to /path/to/clone",
 	)
 
+	info.AddExample(
+		"--prune https://rbinstall.kaos.st /path/to/clone",
+		"Update clone in /path/to/clone and remove files deleted from EK repository",
+	)
+
+	info.AddExample(
+		"--workers 8 --limit-rate 20M https://rbinstall.kaos.st /path/to/clone",
+		"Clone EK repository using 8 parallel downloads with total speed limited to 20 MB/s",
+	)
+
+	info.AddExample(
+		"--dist el-9 --arch x64 --category ruby --no-eol --key mirror.key https://rbinstall.kaos.st /path/to/clone",
+		"Clone only non-EOL CRuby versions for EL 9 on x64 and sign filtered index with your own key",
+	)
+
+	info.AddExample(
+		"--watch 1h --prune https://rbinstall.kaos.st /path/to/clone",
+		"Check EK repository for changes every hour and sync data to /path/to/clone",
+	)
+
+	info.AddExample(
+		"--serve :8080 /path/to/clone",
+		"Serve cloned repository from /path/to/clone on port 8080",
+	)
+
 	return info
 }
 
@@ -0,0 +1,191 @@
+package clone
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// repoFilter contains filters for repository data
+type repoFilter struct {
+	Dists      []string
+	Archs      []string
+	Categories []string
+	Names      []string // Version name globs
+	NoEOL      bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getRepoFilter creates filter using options (returns nil if there are no filters)
+func getRepoFilter() (*repoFilter, error) {
+	f := &repoFilter{
+		Dists:      splitOptionValue(OPT_DIST),
+		Archs:      splitOptionValue(OPT_ARCH),
+		Categories: splitOptionValue(OPT_CATEGORY),
+		Names:      splitOptionValue(OPT_NAME),
+		NoEOL:      options.GetB(OPT_NO_EOL),
+	}
+
+	if f.IsEmpty() {
+		return nil, nil
+	}
+
+	for _, category := range f.Categories {
+		switch category {
+		case index.CATEGORY_RUBY, index.CATEGORY_JRUBY,
+			index.CATEGORY_TRUFFLE, index.CATEGORY_OTHER:
+			// ok
+		default:
+			return nil, fmt.Errorf("Unknown category %q", category)
+		}
+	}
+
+	for _, name := range f.Names {
+		_, err := path.Match(name, "")
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version name pattern %q", name)
+		}
+	}
+
+	return f, nil
+}
+
+// IsEmpty returns true if filter doesn't contain any conditions
+func (f *repoFilter) IsEmpty() bool {
+	return len(f.Dists) == 0 && len(f.Archs) == 0 && len(f.Categories) == 0 &&
+		len(f.Names) == 0 && !f.NoEOL
+}
+
+// Apply creates new index which contains only data matching filter
+func (f *repoFilter) Apply(i *index.Index) *index.Index {
+	result := &index.Index{
+		UUID: i.UUID,
+		Meta: &index.Metadata{},
+		Data: make(index.Data),
+	}
+
+	dists := slices.Clone(f.Dists)
+
+	// Dist can be defined with alias
+	for j, dist := range dists {
+		if i.Aliases[dist] != "" {
+			dists[j] = i.Aliases[dist]
+		}
+	}
+
+	for distName, dist := range i.Data {
+		if len(dists) != 0 && !slices.Contains(dists, distName) {
+			continue
+		}
+
+		for archName, arch := range dist {
+			if len(f.Archs) != 0 && !slices.Contains(f.Archs, archName) {
+				continue
+			}
+
+			for categoryName, category := range arch {
+				if len(f.Categories) != 0 && !slices.Contains(f.Categories, categoryName) {
+					continue
+				}
+
+				for _, version := range category {
+					if f.isMatch(version) {
+						result.Add(distName, archName, categoryName, f.filterVariations(version))
+					}
+				}
+			}
+		}
+	}
+
+	for alias, dist := range i.Aliases {
+		if result.Data[dist] == nil {
+			continue
+		}
+
+		if result.Aliases == nil {
+			result.Aliases = make(map[string]string)
+		}
+
+		result.Aliases[alias] = dist
+	}
+
+	result.UpdateMeta()
+
+	// Filtered index contains the same data as original, so it must have the
+	// same creation date
+	result.Meta.Created = i.Meta.Created
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isMatch returns true if base version matches filter
+func (f *repoFilter) isMatch(version *index.VersionInfo) bool {
+	if f.NoEOL && version.EOL {
+		return false
+	}
+
+	if len(f.Names) == 0 {
+		return true
+	}
+
+	for _, name := range f.Names {
+		// Variations are cloned with base version, so only base version
+		// name is checked
+		if ok, _ := path.Match(name, version.Name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterVariations returns copy of version info without EOL variations
+func (f *repoFilter) filterVariations(version *index.VersionInfo) *index.VersionInfo {
+	if !f.NoEOL || len(version.Variations) == 0 {
+		return version
+	}
+
+	info := *version
+	info.Variations = nil
+
+	for _, variation := range version.Variations {
+		if !variation.EOL {
+			info.Variations = append(info.Variations, variation)
+		}
+	}
+
+	return &info
+}
+
+// splitOptionValue returns slice with comma-separated values of given option
+func splitOptionValue(name string) []string {
+	var result []string
+
+	for _, value := range strings.Split(options.GetS(name), ",") {
+		value = strings.TrimSpace(value)
+
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
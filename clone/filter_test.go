@@ -0,0 +1,189 @@
+package clone
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/essentialkaos/rbinstall/index"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestFilterIsEmpty(t *testing.T) {
+	tests := []struct {
+		Filter  *repoFilter
+		IsEmpty bool
+	}{
+		{&repoFilter{}, true},
+		{&repoFilter{Dists: []string{"el8"}}, false},
+		{&repoFilter{Archs: []string{"x86_64"}}, false},
+		{&repoFilter{Categories: []string{index.CATEGORY_RUBY}}, false},
+		{&repoFilter{Names: []string{"3.3.*"}}, false},
+		{&repoFilter{NoEOL: true}, false},
+	}
+
+	for _, tt := range tests {
+		if tt.Filter.IsEmpty() != tt.IsEmpty {
+			t.Errorf("IsEmpty for %+v must be %t", tt.Filter, tt.IsEmpty)
+		}
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Filter   *repoFilter
+		Versions []string
+		Aliases  []string
+	}{
+		{
+			"no filters", &repoFilter{},
+			[]string{
+				"el8/aarch64/ruby/3.3.6", "el8/x86_64/jruby/jruby-9.4.8.0",
+				"el8/x86_64/ruby/2.7.8", "el8/x86_64/ruby/2.7.8-jemalloc",
+				"el8/x86_64/ruby/3.3.6", "el8/x86_64/ruby/3.3.6-jemalloc",
+				"el9/x86_64/ruby/3.3.6",
+			},
+			[]string{"centos8", "centos9"},
+		},
+		{
+			"dist", &repoFilter{Dists: []string{"el9"}},
+			[]string{"el9/x86_64/ruby/3.3.6"},
+			[]string{"centos9"},
+		},
+		{
+			"dist alias", &repoFilter{Dists: []string{"centos9"}},
+			[]string{"el9/x86_64/ruby/3.3.6"},
+			[]string{"centos9"},
+		},
+		{
+			"arch", &repoFilter{Archs: []string{"aarch64"}},
+			[]string{"el8/aarch64/ruby/3.3.6"},
+			[]string{"centos8"},
+		},
+		{
+			"category", &repoFilter{Categories: []string{index.CATEGORY_JRUBY}},
+			[]string{"el8/x86_64/jruby/jruby-9.4.8.0"},
+			[]string{"centos8"},
+		},
+		{
+			"name", &repoFilter{Dists: []string{"el8"}, Names: []string{"3.3.*", "jruby-*"}},
+			[]string{
+				"el8/aarch64/ruby/3.3.6", "el8/x86_64/jruby/jruby-9.4.8.0",
+				"el8/x86_64/ruby/3.3.6", "el8/x86_64/ruby/3.3.6-jemalloc",
+			},
+			[]string{"centos8"},
+		},
+		{
+			"no EOL", &repoFilter{Archs: []string{"x86_64"}, NoEOL: true},
+			[]string{
+				"el8/x86_64/jruby/jruby-9.4.8.0", "el8/x86_64/ruby/3.3.6",
+				"el9/x86_64/ruby/3.3.6",
+			},
+			[]string{"centos8", "centos9"},
+		},
+		{
+			"no matches", &repoFilter{Names: []string{"4.*"}},
+			nil, nil,
+		},
+	}
+
+	original := getTestIndex()
+
+	for _, tt := range tests {
+		result := tt.Filter.Apply(original)
+
+		if result.UUID != original.UUID {
+			t.Errorf("[%s] Filtered index must have the same UUID", tt.Name)
+		}
+
+		if result.Meta.Created != original.Meta.Created {
+			t.Errorf("[%s] Filtered index must have the same creation date", tt.Name)
+		}
+
+		versions := getIndexVersions(result)
+
+		if !slices.Equal(versions, tt.Versions) {
+			t.Errorf("[%s] Filtered index contains %v, want %v", tt.Name, versions, tt.Versions)
+		}
+
+		if result.Meta.Items != len(tt.Versions) {
+			t.Errorf("[%s] Filtered index has %d items, want %d", tt.Name, result.Meta.Items, len(tt.Versions))
+		}
+
+		var aliases []string
+
+		for alias := range result.Aliases {
+			aliases = append(aliases, alias)
+		}
+
+		slices.Sort(aliases)
+
+		if !slices.Equal(aliases, tt.Aliases) {
+			t.Errorf("[%s] Filtered index contains aliases %v, want %v", tt.Name, aliases, tt.Aliases)
+		}
+	}
+
+	if len(getIndexVersions(original)) != 7 {
+		t.Errorf("Original index must not be modified by filter")
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getTestIndex returns index with test data
+func getTestIndex() *index.Index {
+	i := index.NewIndex()
+	i.Aliases = map[string]string{"centos8": "el8", "centos9": "el9"}
+
+	i.Add("el8", "x86_64", index.CATEGORY_RUBY, &index.VersionInfo{
+		Name: "2.7.8", Size: 100, EOL: true,
+		Variations: []*index.VersionInfo{{Name: "2.7.8-jemalloc", Size: 100}},
+	})
+
+	i.Add("el8", "x86_64", index.CATEGORY_RUBY, &index.VersionInfo{
+		Name: "3.3.6", Size: 100,
+		Variations: []*index.VersionInfo{{Name: "3.3.6-jemalloc", Size: 100, EOL: true}},
+	})
+
+	i.Add("el8", "x86_64", index.CATEGORY_JRUBY, &index.VersionInfo{Name: "jruby-9.4.8.0", Size: 100})
+	i.Add("el8", "aarch64", index.CATEGORY_RUBY, &index.VersionInfo{Name: "3.3.6", Size: 100})
+	i.Add("el9", "x86_64", index.CATEGORY_RUBY, &index.VersionInfo{Name: "3.3.6", Size: 100})
+
+	i.UpdateMeta()
+	i.Meta.Created = 1700000000
+
+	return i
+}
+
+// getIndexVersions returns sorted slice with all versions in index in
+// dist/arch/category/name format
+func getIndexVersions(i *index.Index) []string {
+	var result []string
+
+	for distName, dist := range i.Data {
+		for archName, arch := range dist {
+			for categoryName, category := range arch {
+				for _, version := range category {
+					prefix := distName + "/" + archName + "/" + categoryName + "/"
+					result = append(result, prefix+version.Name)
+
+					for _, variation := range version.Variations {
+						result = append(result, prefix+variation.Name)
+					}
+				}
+			}
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
@@ -0,0 +1,92 @@
+package clone
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// LIMITER_CHUNK_SIZE is max size of data read at once by limited reader
+const LIMITER_CHUNK_SIZE = 16 * 1024
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// rateLimiter limits total speed of all downloads
+type rateLimiter struct {
+	rate int64     // Bytes per second
+	next time.Time // Time when next chunk of data can be transferred
+	mx   sync.Mutex
+}
+
+// limitedReader is reader with limited read speed
+type limitedReader struct {
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newRateLimiter creates new rate limiter with given speed in bytes per second
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{rate: rate}
+}
+
+// Reader returns reader with limited read speed
+func (l *rateLimiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+
+	return &limitedReader{r: r, limiter: l}
+}
+
+// Wait blocks until given number of bytes can be transferred
+func (l *rateLimiter) Wait(size int) {
+	l.mx.Lock()
+
+	now := time.Now()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(size) * int64(time.Second) / l.rate))
+
+	l.mx.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Read reads data from underlying reader with respect to speed limit
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// Read data in small chunks to keep speed smooth
+	if len(p) > LIMITER_CHUNK_SIZE {
+		p = p[:LIMITER_CHUNK_SIZE]
+	}
+
+	n, err := r.r.Read(p)
+
+	if n > 0 {
+		r.limiter.Wait(n)
+	}
+
+	return n, err
+}
@@ -0,0 +1,338 @@
+package clone
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/hashutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+
+	"github.com/essentialkaos/rbinstall/index"
+	"github.com/essentialkaos/rbinstall/sign"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HEALTH_PATH is path of health check endpoint
+const HEALTH_PATH = "/_health"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// repoServer is HTTP server for cloned repository
+type repoServer struct {
+	dir       string
+	accessLog io.Writer
+	logMx     sync.Mutex
+
+	index *servedIndex
+	mx    sync.RWMutex
+}
+
+// servedIndex contains info about index of served repository
+type servedIndex struct {
+	UUID    string
+	ETag    string
+	Items   int
+	ModTime time.Time
+	Hashes  map[string]string // Relative path → SHA-256 hash
+}
+
+// healthStatus contains info about server health
+type healthStatus struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	UUID    string `json:"uuid,omitempty"`
+	Items   int    `json:"items,omitempty"`
+	Updated int64  `json:"updated,omitempty"`
+}
+
+// accessLogWriter is response writer which records response info for access log
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// serveRepository serves cloned repository in given directory over HTTP
+func serveRepository(addr, dir string) {
+	srv := &repoServer{dir: dir, accessLog: os.Stdout}
+
+	if options.Has(OPT_ACCESS_LOG) {
+		fd, err := os.OpenFile(options.GetS(OPT_ACCESS_LOG), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+		if err != nil {
+			printErrorAndExit("Can't open access log: %v", err)
+		}
+
+		defer fd.Close()
+
+		srv.accessLog = fd
+	}
+
+	_, err := srv.getIndex()
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		printErrorAndExit("Can't start HTTP server: %v", err)
+	}
+
+	fmtc.Printfn("Serving {*}%s{!} on {*}%s{!}…", dir, listener.Addr())
+
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		<-sigs
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		httpServer.Shutdown(ctx)
+	}()
+
+	err = httpServer.Serve(listener)
+
+	if err != nil && err != http.ErrServerClosed {
+		printErrorAndExit("HTTP server error: %v", err)
+	}
+
+	fmtc.Println("{s}Server stopped{!}")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ServeHTTP handles HTTP requests
+func (s *repoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	lw := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+
+	switch {
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		lw.Header().Set("Allow", "GET, HEAD")
+		http.Error(lw, "Method not allowed", http.StatusMethodNotAllowed)
+	case r.URL.Path == HEALTH_PATH:
+		s.serveHealth(lw)
+	default:
+		s.serveFile(lw, r)
+	}
+
+	s.writeAccessLog(r, lw, time.Since(start))
+}
+
+// serveHealth serves health check endpoint
+func (s *repoServer) serveHealth(w http.ResponseWriter) {
+	status := &healthStatus{Status: "ok"}
+	i, err := s.getIndex()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err != nil {
+		status.Status, status.Error = "error", err.Error()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		status.UUID, status.Items, status.Updated = i.UUID, i.Items, i.ModTime.Unix()
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// serveFile serves file from repository
+func (s *repoServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	relPath := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+
+	// Hidden files (e.g. partially downloaded data) are never served
+	if relPath == "" || strings.HasPrefix(relPath, ".") || strings.Contains(relPath, "/.") {
+		http.NotFound(w, r)
+		return
+	}
+
+	file := path.Join(s.dir, relPath)
+
+	if !fsutil.IsRegular(file) {
+		http.NotFound(w, r)
+		return
+	}
+
+	i, err := s.getIndex()
+
+	if err != nil {
+		http.Error(w, "Repository index is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Client expects file with particular hash, so we must not serve stale data
+	reqHash := r.URL.Query().Get("hash")
+
+	if reqHash != "" && i.Hashes[relPath] != reqHash {
+		http.Error(w, "File with given hash not found", http.StatusNotFound)
+		return
+	}
+
+	fd, err := os.Open(file)
+
+	if err != nil {
+		http.Error(w, "Can't read file", http.StatusInternalServerError)
+		return
+	}
+
+	defer fd.Close()
+
+	stat, err := fd.Stat()
+
+	if err != nil {
+		http.Error(w, "Can't read file", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case relPath == INDEX_NAME:
+		w.Header().Set("ETag", i.ETag)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Type", "application/json")
+	case relPath == INDEX_NAME+sign.EXTENSION:
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Type", "text/plain")
+	case strings.HasSuffix(relPath, ".tzst"):
+		w.Header().Set("Content-Type", "application/zstd")
+	case strings.HasSuffix(relPath, index.MANIFEST_EXTENSION):
+		w.Header().Set("Content-Type", "application/json")
+	default:
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+
+	// Archives and manifests are immutable, so hash can be used as ETag
+	if i.Hashes[relPath] != "" {
+		w.Header().Set("ETag", `"`+i.Hashes[relPath]+`"`)
+	}
+
+	http.ServeContent(w, r, path.Base(file), stat.ModTime(), fd)
+}
+
+// getIndex returns info about repository index, index is re-read if it was
+// changed since last read
+func (s *repoServer) getIndex() (*servedIndex, error) {
+	indexFile := path.Join(s.dir, INDEX_NAME)
+	modTime, err := fsutil.GetMTime(indexFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read index: %w", err)
+	}
+
+	s.mx.RLock()
+	i := s.index
+	s.mx.RUnlock()
+
+	if i != nil && i.ModTime.Equal(modTime) {
+		return i, nil
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// Index could be read by another request while we were waiting for lock
+	if s.index != nil && s.index.ModTime.Equal(modTime) {
+		return s.index, nil
+	}
+
+	indexData, err := os.ReadFile(indexFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read index: %w", err)
+	}
+
+	repoIndex := &index.Index{}
+	err = json.Unmarshal(indexData, repoIndex)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode index: %w", err)
+	}
+
+	i = &servedIndex{
+		UUID:    repoIndex.UUID,
+		ETag:    `"` + hashutil.Bytes(indexData, sha256.New()).String() + `"`,
+		ModTime: modTime,
+		Hashes:  make(map[string]string),
+	}
+
+	if repoIndex.Meta != nil {
+		i.Items = repoIndex.Meta.Items
+	}
+
+	for _, item := range getItems(repoIndex, "") {
+		if item.Hash != "" {
+			i.Hashes[item.OS+"/"+item.Arch+"/"+item.File] = item.Hash
+		}
+	}
+
+	s.index = i
+
+	return i, nil
+}
+
+// writeAccessLog writes request info to access log in combined log format
+func (s *repoServer) writeAccessLog(r *http.Request, w *accessLogWriter, dur time.Duration) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	s.logMx.Lock()
+	defer s.logMx.Unlock()
+
+	fmt.Fprintf(
+		s.accessLog, "%s - - [%s] %q %d %d %q %q %.3f\n",
+		host, time.Now().Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		w.status, w.size, r.Referer(), r.UserAgent(), dur.Seconds(),
+	)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// WriteHeader records status code and sends response header
+func (w *accessLogWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records size of response body and writes data
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+	return n, err
+}
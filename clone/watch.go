@@ -0,0 +1,245 @@
+package clone
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/req"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/timeutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// STATUS_NAME is default name of file with sync status
+const STATUS_NAME = ".sync-status.json"
+
+// MIN_WATCH_INTERVAL is minimal interval between index checks
+const MIN_WATCH_INTERVAL = time.Minute
+
+// Sync results
+const (
+	SYNC_RESULT_SYNCED      = "synced"
+	SYNC_RESULT_UP_TO_DATE  = "up-to-date"
+	SYNC_RESULT_FAILED      = "failed"
+	SYNC_RESULT_INTERRUPTED = "interrupted"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// indexValidators contains validators of remote index used for conditional
+// requests
+type indexValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// syncStatus contains info about last sync
+type syncStatus struct {
+	URL        string    `json:"url"`
+	UUID       string    `json:"uuid,omitempty"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	LastCheck  time.Time `json:"last_check"`
+	LastSync   time.Time `json:"last_sync"`
+	Added      int       `json:"added"`
+	Updated    int       `json:"updated"`
+	Removed    int       `json:"removed"`
+	Unchanged  int       `json:"unchanged"`
+	Downloaded int64     `json:"downloaded"`
+}
+
+// repoWatcher periodically syncs local copy of repository with remote one
+type repoWatcher struct {
+	url        string
+	dir        string
+	statusFile string
+	status     *syncStatus
+	validators *indexValidators
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// errNotModified is returned if remote index wasn't modified since last check
+var errNotModified = errors.New("Index is not modified")
+
+// errInterrupted is returned if sync was interrupted by signal
+var errInterrupted = errors.New("Sync interrupted by signal")
+
+// interrupted is true if process received signal for termination
+var interrupted atomic.Bool
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// watchRepository periodically checks remote repository for changes and syncs
+// data if index was changed
+func watchRepository(url, dir string, interval time.Duration) {
+	w := &repoWatcher{
+		url:        url,
+		dir:        dir,
+		statusFile: options.GetS(OPT_STATUS_FILE),
+		status:     &syncStatus{URL: url},
+		validators: &indexValidators{},
+	}
+
+	if w.statusFile == "" {
+		w.statusFile = path.Join(dir, STATUS_NAME)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	done := make(chan bool)
+
+	fmtc.Printfn(
+		"Watching {*}%s{!} for changes every {*}%s{!}…",
+		url, timeutil.PrettyDuration(interval),
+	)
+
+	for {
+		go func() {
+			w.Sync()
+			done <- true
+		}()
+
+		select {
+		case <-done:
+			// continue
+		case <-sigs:
+			// Wait until started downloads are finished
+			fmtc.Println("{s}Got termination signal, waiting for started downloads…{!}")
+			interrupted.Store(true)
+			<-done
+			fmtc.Println("{s}Watching stopped{!}")
+			return
+		}
+
+		select {
+		case <-time.After(interval):
+			// continue
+		case <-sigs:
+			fmtc.Println("{s}Watching stopped{!}")
+			return
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Sync checks remote index and syncs repository data if index was changed
+func (w *repoWatcher) Sync() {
+	w.status.LastCheck = time.Now()
+
+	fmtc.Printfn(
+		"{s}[%s]{!} Checking index on {*}%s{!}…",
+		timeutil.Format(w.status.LastCheck, "%Y/%m/%d %H:%M:%S"), w.url,
+	)
+
+	i, indexData, indexSig, err := fetchRepositoryIndex(w.url, w.validators)
+
+	switch {
+	case err == errNotModified:
+		w.status.Result, w.status.Error = SYNC_RESULT_UP_TO_DATE, ""
+		fmtc.Println("{g}Index is not modified since last check{!}")
+
+	case err != nil:
+		w.setError(err)
+
+	case isSameData(i, w.dir):
+		w.status.UUID = i.UUID
+		w.status.Result, w.status.Error = SYNC_RESULT_UP_TO_DATE, ""
+		fmtc.Println("{g}Looks like you already have the same set of data{!}")
+
+	default:
+		fmtc.Printfn("Index was changed {s-}(UUID: %s){!}, syncing data…", i.UUID)
+
+		stats, err := syncRepository(i, indexData, indexSig, w.url, w.dir)
+
+		w.status.Added, w.status.Updated = stats.Added, stats.Updated
+		w.status.Removed, w.status.Unchanged = stats.Removed, stats.Unchanged
+		w.status.Downloaded = stats.Downloaded
+
+		if err != nil {
+			w.setError(err)
+			break
+		}
+
+		printCloneSummary(stats)
+
+		w.status.UUID, w.status.LastSync = i.UUID, time.Now()
+		w.status.Result, w.status.Error = SYNC_RESULT_SYNCED, ""
+	}
+
+	err = w.saveStatus()
+
+	if err != nil {
+		terminal.Error(err)
+	}
+
+	fmtc.NewLine()
+}
+
+// setError updates status with info about failed sync
+func (w *repoWatcher) setError(err error) {
+	// Reset validators, so index will be fetched and synced on next check
+	w.validators = &indexValidators{}
+
+	if err == errInterrupted {
+		w.status.Result = SYNC_RESULT_INTERRUPTED
+	} else {
+		w.status.Result = SYNC_RESULT_FAILED
+	}
+
+	w.status.Error = err.Error()
+
+	terminal.Error(err)
+}
+
+// saveStatus saves sync status to file
+func (w *repoWatcher) saveStatus() error {
+	data, err := json.MarshalIndent(w.status, "", "  ")
+
+	if err != nil {
+		return fmtc.Errorf("Can't encode sync status: %v", err)
+	}
+
+	err = writeFile(w.statusFile, data)
+
+	if err != nil {
+		return fmtc.Errorf("Can't save sync status to %s: %v", w.statusFile, err)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Headers returns headers for conditional request
+func (v *indexValidators) Headers() req.Headers {
+	headers := req.Headers{}
+
+	if v.ETag != "" {
+		headers["If-None-Match"] = v.ETag
+	}
+
+	if v.LastModified != "" {
+		headers["If-Modified-Since"] = v.LastModified
+	}
+
+	return headers
+}
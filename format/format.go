@@ -0,0 +1,241 @@
+package format
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Supported formats
+const (
+	JSON = "json"
+	YAML = "yaml"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// keyValue is key-value pair of object
+type keyValue struct {
+	Key   string
+	Value any
+}
+
+// object is object with ordered keys
+type object []keyValue
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsSupported returns true if given format is supported
+func IsSupported(format string) bool {
+	switch format {
+	case JSON, YAML:
+		return true
+	}
+
+	return false
+}
+
+// Marshal encodes given value using given format
+func Marshal(v any, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case JSON:
+		return append(data, '\n'), nil
+	case YAML:
+		return convertToYAML(data)
+	}
+
+	return nil, fmt.Errorf("Unsupported format %q", format)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// convertToYAML converts JSON data to YAML
+func convertToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err := readNode(dec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("---\n")
+
+	if isScalar(node) {
+		buf.WriteString(formatScalar(node) + "\n")
+	} else {
+		writeBlock(&buf, node, 0, false)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// readNode reads JSON node preserving order of object keys
+func readNode(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		result := object{}
+
+		for dec.More() {
+			keyToken, err := dec.Token()
+
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := readNode(dec)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, keyValue{fmt.Sprint(keyToken), value})
+		}
+
+		_, err = dec.Token()
+
+		return result, err
+
+	case '[':
+		result := []any{}
+
+		for dec.More() {
+			value, err := readNode(dec)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, value)
+		}
+
+		_, err = dec.Token()
+
+		return result, err
+	}
+
+	return nil, fmt.Errorf("Unexpected delimiter %v", delim)
+}
+
+// writeBlock writes object or array as YAML block
+func writeBlock(buf *bytes.Buffer, node any, indent int, inlineFirst bool) {
+	switch n := node.(type) {
+	case object:
+		for i, kv := range n {
+			if i != 0 || !inlineFirst {
+				buf.WriteString(strings.Repeat(" ", indent))
+			}
+
+			buf.WriteString(formatKey(kv.Key) + ":")
+			writeChild(buf, kv.Value, indent+2)
+		}
+
+	case []any:
+		for i, item := range n {
+			if i != 0 || !inlineFirst {
+				buf.WriteString(strings.Repeat(" ", indent))
+			}
+
+			switch {
+			case isScalar(item):
+				buf.WriteString("- " + formatScalar(item) + "\n")
+			case isObject(item):
+				buf.WriteString("- ")
+				writeBlock(buf, item, indent+2, true)
+			default:
+				buf.WriteString("-\n")
+				writeBlock(buf, item, indent+2, false)
+			}
+		}
+	}
+}
+
+// writeChild writes value of object property
+func writeChild(buf *bytes.Buffer, node any, indent int) {
+	if isScalar(node) {
+		buf.WriteString(" " + formatScalar(node) + "\n")
+		return
+	}
+
+	buf.WriteString("\n")
+	writeBlock(buf, node, indent, false)
+}
+
+// isScalar returns true if given node is scalar or empty collection
+func isScalar(node any) bool {
+	switch n := node.(type) {
+	case object:
+		return len(n) == 0
+	case []any:
+		return len(n) == 0
+	}
+
+	return true
+}
+
+// isObject returns true if given node is non-empty object
+func isObject(node any) bool {
+	n, ok := node.(object)
+	return ok && len(n) != 0
+}
+
+// formatKey formats object key
+func formatKey(key string) string {
+	if key == "" || strings.Trim(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+		return formatScalar(key)
+	}
+
+	return key
+}
+
+// formatScalar formats scalar value
+func formatScalar(node any) string {
+	switch n := node.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(n)
+	case json.Number:
+		return n.String()
+	case string:
+		data, _ := json.Marshal(n)
+		return string(data)
+	case object:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+
+	return fmt.Sprint(node)
+}
@@ -0,0 +1,120 @@
+package format
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type testRecord struct {
+	Name       string           `json:"name"`
+	Size       int              `json:"size"`
+	EOL        bool             `json:"eol"`
+	Tags       []string         `json:"tags"`
+	Variations []map[string]any `json:"variations,omitempty"`
+	Extra      any              `json:"extra"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestIsSupported(t *testing.T) {
+	tests := []struct {
+		Format      string
+		IsSupported bool
+	}{
+		{JSON, true},
+		{YAML, true},
+		{"", false},
+		{"xml", false},
+		{"JSON", false},
+	}
+
+	for _, tt := range tests {
+		if IsSupported(tt.Format) != tt.IsSupported {
+			t.Errorf("IsSupported(%q) must be %t", tt.Format, tt.IsSupported)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := Marshal(testRecord{Name: "3.3.6", Size: 10, Tags: []string{"a"}}, JSON)
+
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	expected := "{\n  \"name\": \"3.3.6\",\n  \"size\": 10,\n  \"eol\": false,\n" +
+		"  \"tags\": [\n    \"a\"\n  ],\n  \"extra\": null\n}\n"
+
+	if string(data) != expected {
+		t.Errorf("Marshal returned %q, want %q", data, expected)
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Value any
+		YAML  string
+	}{
+		{"string", "test", "---\n\"test\"\n"},
+		{"number", 42, "---\n42\n"},
+		{"null", nil, "---\nnull\n"},
+		{"empty array", []string{}, "---\n[]\n"},
+		{"empty object", map[string]int{}, "---\n{}\n"},
+		{
+			"struct",
+			testRecord{
+				Name: "3.3.6", Size: 10,
+				Tags:       []string{"a", "b: c"},
+				Variations: []map[string]any{{"x": 1, "y": []int{1, 2}}},
+			},
+			"---\nname: \"3.3.6\"\nsize: 10\neol: false\ntags:\n  - \"a\"\n  - \"b: c\"\n" +
+				"variations:\n  - x: 1\n    y:\n      - 1\n      - 2\nextra: null\n",
+		},
+		{
+			"nested arrays",
+			[][]int{{1, 2}, {3}},
+			"---\n-\n  - 1\n  - 2\n-\n  - 3\n",
+		},
+		{
+			"special keys",
+			map[string]any{"key with space": "v", "": 1, "n": map[string]any{"m": true}},
+			"---\n\"\": 1\n\"key with space\": \"v\"\nn:\n  m: true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := Marshal(tt.Value, YAML)
+
+		if err != nil {
+			t.Errorf("[%s] Marshal returned error: %v", tt.Name, err)
+			continue
+		}
+
+		if string(data) != tt.YAML {
+			t.Errorf("[%s] Marshal returned %q, want %q", tt.Name, data, tt.YAML)
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	_, err := Marshal("test", "xml")
+
+	if err == nil {
+		t.Errorf("Marshal with unsupported format must return error")
+	}
+
+	_, err = Marshal(make(chan bool), YAML)
+
+	if err == nil {
+		t.Errorf("Marshal with unsupported value must return error")
+	}
+}
@@ -8,6 +8,8 @@ package gen
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bufio"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"fmt"
 	"os"
@@ -36,7 +38,10 @@ import (
 	"github.com/essentialkaos/ek/v13/usage/completion/zsh"
 	"github.com/essentialkaos/ek/v13/usage/man"
 
+	"github.com/essentialkaos/npck/tzst"
+
 	"github.com/essentialkaos/rbinstall/index"
+	"github.com/essentialkaos/rbinstall/sign"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -50,12 +55,15 @@ const (
 
 // Options
 const (
-	OPT_OUTPUT   = "o:output"
-	OPT_EOL      = "e:eol"
-	OPT_ALIAS    = "a:alias"
-	OPT_NO_COLOR = "nc:no-color"
-	OPT_HELP     = "h:help"
-	OPT_VER      = "v:version"
+	OPT_OUTPUT      = "o:output"
+	OPT_EOL         = "e:eol"
+	OPT_ALIAS       = "a:alias"
+	OPT_KEY         = "k:key"
+	OPT_KEYGEN      = "K:keygen"
+	OPT_NO_MANIFEST = "M:no-manifest"
+	OPT_NO_COLOR    = "nc:no-color"
+	OPT_HELP        = "h:help"
+	OPT_VER         = "v:version"
 
 	OPT_VERB_VER     = "vv:verbose-version"
 	OPT_COMPLETION   = "completion"
@@ -91,14 +99,18 @@ func (s fileInfoSlice) Less(i, j int) bool {
 
 var eolInfo map[string]bool
 var aliasInfo map[string]string
+var signKey ed25519.PrivateKey
 
 var optMap = options.Map{
-	OPT_OUTPUT:   {Value: INDEX_NAME},
-	OPT_EOL:      {Value: "eol.json"},
-	OPT_ALIAS:    {Value: "alias.json"},
-	OPT_NO_COLOR: {Type: options.BOOL},
-	OPT_HELP:     {Type: options.BOOL},
-	OPT_VER:      {Type: options.MIXED},
+	OPT_OUTPUT:      {Value: INDEX_NAME},
+	OPT_EOL:         {Value: "eol.json"},
+	OPT_ALIAS:       {Value: "alias.json"},
+	OPT_KEY:         {},
+	OPT_KEYGEN:      {},
+	OPT_NO_MANIFEST: {Type: options.BOOL},
+	OPT_NO_COLOR:    {Type: options.BOOL},
+	OPT_HELP:        {Type: options.BOOL},
+	OPT_VER:         {Type: options.MIXED},
 
 	OPT_VERB_VER:     {Type: options.BOOL},
 	OPT_COMPLETION:   {},
@@ -139,6 +151,9 @@ func Run(gitRev string, gomod []byte) {
 		support.Collect(APP, VER).WithRevision(gitRev).
 			WithDeps(deps.Extract(gomod)).Print()
 		os.Exit(0)
+	case options.Has(OPT_KEYGEN):
+		generateKeys(options.GetS(OPT_KEYGEN))
+		os.Exit(0)
 	case options.GetB(OPT_HELP) || len(args) == 0:
 		genUsage().Print()
 		os.Exit(0)
@@ -148,6 +163,7 @@ func Run(gitRev string, gomod []byte) {
 
 	loadEOLInfo()
 	loadAliasInfo()
+	loadSignKey()
 	checkDir(dataDir)
 	buildIndex(dataDir)
 }
@@ -209,6 +225,51 @@ func loadAliasInfo() {
 	}
 }
 
+// loadSignKey loads private key used for signing index
+func loadSignKey() {
+	if !options.Has(OPT_KEY) {
+		return
+	}
+
+	var err error
+
+	signKey, err = sign.ReadPrivateKey(options.GetS(OPT_KEY))
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+}
+
+// generateKeys generates new pair of keys for signing index
+func generateKeys(name string) {
+	pubKeyFile, privKeyFile := name+".pub", name+".key"
+
+	if fsutil.IsExist(pubKeyFile) || fsutil.IsExist(privKeyFile) {
+		printErrorAndExit("Keys %s or %s already exist", pubKeyFile, privKeyFile)
+	}
+
+	pubKey, privKey, err := sign.GenerateKeys()
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	err = os.WriteFile(privKeyFile, []byte(privKey+"\n"), 0600)
+
+	if err != nil {
+		printErrorAndExit("Can't save private key: %v", err)
+	}
+
+	err = os.WriteFile(pubKeyFile, []byte(pubKey+"\n"), 0644)
+
+	if err != nil {
+		printErrorAndExit("Can't save public key: %v", err)
+	}
+
+	fmtc.Printfn("{g}Private key saved as {*}%s{!*}, public key saved as {*}%s{!}", privKeyFile, pubKeyFile)
+	fmtc.Printfn("{s-}Add public key {s}%s{s-} to storage:keys in rbinstall configuration file{!}", pubKey)
+}
+
 // checkDir do some checks for provided dir
 func checkDir(dataDir string) {
 	if !fsutil.IsDir(dataDir) {
@@ -285,6 +346,24 @@ func buildIndex(dataDir string) {
 			versionInfo.Hash = hashutil.File(filePath, sha256.New()).String()
 		}
 
+		if signKey != nil {
+			versionInfo.Signature = sign.Sign(versionInfo.SignatureData(), signKey)
+		}
+
+		if !options.GetB(OPT_NO_MANIFEST) {
+			var err error
+
+			if alreadyExist {
+				versionInfo.Manifest, err = processManifest(filePath, fileName, oldVersionInfo)
+			} else {
+				versionInfo.Manifest, err = processManifest(filePath, fileName, nil)
+			}
+
+			if err != nil {
+				terminal.Warn("Can't create file manifest for %s: %v", fileName, err)
+			}
+		}
+
 		if isBaseRubyVariation(fileName) {
 			baseVersionName := getVariationBaseName(fileName)
 			baseVersionInfo, _ := newIndex.Find(fileInfo.OS, fileInfo.Arch, baseVersionName)
@@ -331,6 +410,61 @@ func buildIndex(dataDir string) {
 	)
 }
 
+// processManifest creates file manifest for given archive and returns
+// its hash
+func processManifest(filePath, versionName string, oldVersionInfo *index.VersionInfo) (string, error) {
+	manifestFile := filePath + index.MANIFEST_EXTENSION
+
+	// Manifest can be reused if archive wasn't changed
+	if oldVersionInfo != nil && oldVersionInfo.Manifest != "" && fsutil.IsExist(manifestFile) {
+		if hashutil.File(manifestFile, sha256.New()).EqualString(oldVersionInfo.Manifest) {
+			return oldVersionInfo.Manifest, nil
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "rbinstall-gen-")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	fd, err := os.Open(filePath)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = tzst.Read(bufio.NewReader(fd), tmpDir)
+
+	fd.Close()
+
+	if err != nil {
+		return "", fmt.Errorf("Can't unpack archive: %w", err)
+	}
+
+	manifest, err := index.CreateManifest(versionName, path.Join(tmpDir, versionName))
+
+	if err != nil {
+		return "", err
+	}
+
+	manifestData, err := manifest.Encode()
+
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(manifestFile, manifestData, 0644)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hashutil.Bytes(manifestData, sha256.New()).String(), nil
+}
+
 // isEOLVersion return true if it EOL version
 func isEOLVersion(name string) bool {
 	if len(eolInfo) == 0 {
@@ -452,6 +586,20 @@ func saveIndex(outputFile string, i *index.Index) {
 	}
 
 	os.Chmod(outputFile, 0644)
+
+	sigFile := outputFile + sign.EXTENSION
+
+	if signKey == nil {
+		// Signature from previous index is not valid anymore
+		os.Remove(sigFile)
+		return
+	}
+
+	err = os.WriteFile(sigFile, []byte(sign.Sign(indexData, signKey)), 0644)
+
+	if err != nil {
+		printErrorAndExit("Can't save index signature: %v", err)
+	}
 }
 
 // guessCategory try to guess category by file name
@@ -558,6 +706,9 @@ func genUsage() *usage.Info {
 	info.AddOption(OPT_OUTPUT, "Custom index output {s-}(default: index.json){!}", "file")
 	info.AddOption(OPT_EOL, "File with EOL information {s-}(default: eol.json){!}", "file")
 	info.AddOption(OPT_ALIAS, "File with aliases information {s-}(default: alias.json){!}", "file")
+	info.AddOption(OPT_KEY, "Private key for signing index and archives", "file")
+	info.AddOption(OPT_KEYGEN, "Generate new pair of keys for signing", "name")
+	info.AddOption(OPT_NO_MANIFEST, "Don't create file manifests for archives")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VER, "Show version")
@@ -572,6 +723,16 @@ func genUsage() *usage.Info {
 		"Generate index for directory /dir/with/rubies and save all all.json",
 	)
 
+	info.AddExample(
+		"-K rbinstall",
+		"Generate keys rbinstall.key and rbinstall.pub for signing index",
+	)
+
+	info.AddExample(
+		"-k rbinstall.key /dir/with/rubies",
+		"Generate index for directory /dir/with/rubies and sign it",
+	)
+
 	return info
 }
 
@@ -0,0 +1,322 @@
+package index
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Constraint is version constraint (e.g. "3.3", "~> 3.2" or ">= 3.1, < 3.3")
+type Constraint []*constraintPart
+
+// constraintPart is part of version constraint
+type constraintPart struct {
+	Op        string // Comparison operator
+	Flavor    string // Name prefix (e.g. "jruby-")
+	Number    []int  // Version number
+	Variation string // Name suffix (e.g. "-jemalloc")
+}
+
+// versionName contains parsed version name
+type versionName struct {
+	Flavor    string
+	Number    []int
+	Variation string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Supported constraint operators
+const (
+	OP_PARTIAL     = ""
+	OP_EQUAL       = "="
+	OP_NOT_EQUAL   = "!="
+	OP_GREATER     = ">"
+	OP_GREATER_EQ  = ">="
+	OP_LESS        = "<"
+	OP_LESS_EQ     = "<="
+	OP_PESSIMISTIC = "~>"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// nameRegex is regex for parsing version name
+var nameRegex = regexp.MustCompile(`^([a-z][a-z-]*-)?([0-9]+(?:\.[0-9]+)*)(-.*)?$`)
+
+// patchLevelRegex is regex for patch level in version name
+var patchLevelRegex = regexp.MustCompile(`^-p[0-9]+`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ParseConstraint parses version constraint
+func ParseConstraint(constraint string) (Constraint, error) {
+	var result Constraint
+
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+
+		if part == "" {
+			return nil, fmt.Errorf("Invalid version constraint %q", constraint)
+		}
+
+		var op string
+
+		for _, o := range []string{
+			OP_PESSIMISTIC, OP_GREATER_EQ, OP_LESS_EQ, OP_NOT_EQUAL,
+			OP_EQUAL, OP_GREATER, OP_LESS,
+		} {
+			if strings.HasPrefix(part, o) {
+				op = o
+				part = strings.TrimSpace(strings.TrimPrefix(part, o))
+				break
+			}
+		}
+
+		name, ok := parseVersionName(part)
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid version constraint %q", constraint)
+		}
+
+		result = append(result, &constraintPart{op, name.Flavor, name.Number, name.Variation})
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Resolve finds the newest version matching given version constraint
+func (i *Index) Resolve(dist, arch, constraint string, eol bool) (*VersionInfo, string, error) {
+	if i == nil {
+		return nil, "", nil
+	}
+
+	c, err := ParseConstraint(constraint)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, category := i.findNewest(dist, arch, func(_ *VersionInfo, name versionName, isEOL bool) bool {
+		return c.match(name) && (eol || !isEOL)
+	})
+
+	return info, category, nil
+}
+
+// FindUpgrade finds the newest release in the same minor line (with the same
+// variation) as version with given name
+func (i *Index) FindUpgrade(dist, arch, name string) (*VersionInfo, string) {
+	if i == nil {
+		return nil, ""
+	}
+
+	return i.findNewest(dist, arch, func(info *VersionInfo, _ versionName, _ bool) bool {
+		return IsSuperseded(name, info.Name)
+	})
+}
+
+// findNewest finds the newest version which satisfies given condition
+func (i *Index) findNewest(dist, arch string, cond func(*VersionInfo, versionName, bool) bool) (*VersionInfo, string) {
+	if i.Aliases[dist] != "" {
+		dist = i.Aliases[dist]
+	}
+
+	if i.Data[dist] == nil || i.Data[dist][arch] == nil {
+		return nil, ""
+	}
+
+	var result *VersionInfo
+	var resultCategory string
+	var resultName versionName
+
+	for categoryName, category := range i.Data[dist][arch] {
+		for _, version := range category {
+			candidates := append([]*VersionInfo{version}, version.Variations...)
+
+			for _, info := range candidates {
+				name, ok := parseVersionName(info.Name)
+
+				// Variation is EOL if base version is EOL
+				if !ok || !cond(info, name, info.EOL || version.EOL) {
+					continue
+				}
+
+				if result == nil || isNewer(name, resultName, info.Name, result.Name) {
+					result, resultCategory, resultName = info, categoryName, name
+				}
+			}
+		}
+	}
+
+	return result, resultCategory
+}
+
+// Match returns true if version with given name matches constraint
+func (c Constraint) Match(name string) bool {
+	v, ok := parseVersionName(name)
+	return ok && c.match(v)
+}
+
+// IsSuperseded returns true if version with given name is superseded by other
+// version with newer patch release in the same minor line
+func IsSuperseded(name, other string) bool {
+	v1, ok1 := parseVersionName(name)
+	v2, ok2 := parseVersionName(other)
+
+	if !ok1 || !ok2 || v1.Flavor != v2.Flavor || v1.Variation != v2.Variation {
+		return false
+	}
+
+	if len(v1.Number) < 2 || len(v2.Number) < 2 ||
+		v1.Number[0] != v2.Number[0] || v1.Number[1] != v2.Number[1] {
+		return false
+	}
+
+	return isNewer(v2, v1, other, name)
+}
+
+// match returns true if parsed version name matches constraint
+func (c Constraint) match(name versionName) bool {
+	for _, part := range c {
+		if !part.match(name) {
+			return false
+		}
+	}
+
+	return len(c) != 0
+}
+
+// match returns true if version name matches constraint part
+func (p *constraintPart) match(name versionName) bool {
+	if p.Flavor != name.Flavor || p.Variation != name.Variation {
+		return false
+	}
+
+	switch p.Op {
+	case OP_PARTIAL:
+		return hasNumberPrefix(name.Number, p.Number)
+	case OP_EQUAL:
+		return compareNumbers(name.Number, p.Number) == 0
+	case OP_NOT_EQUAL:
+		return compareNumbers(name.Number, p.Number) != 0
+	case OP_GREATER:
+		return compareNumbers(name.Number, p.Number) > 0
+	case OP_GREATER_EQ:
+		return compareNumbers(name.Number, p.Number) >= 0
+	case OP_LESS:
+		return compareNumbers(name.Number, p.Number) < 0
+	case OP_LESS_EQ:
+		return compareNumbers(name.Number, p.Number) <= 0
+	case OP_PESSIMISTIC:
+		return compareNumbers(name.Number, p.Number) >= 0 &&
+			compareNumbers(name.Number, getPessimisticLimit(p.Number)) < 0
+	}
+
+	return false
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseVersionName parses version name
+func parseVersionName(name string) (versionName, bool) {
+	m := nameRegex.FindStringSubmatch(strings.ToLower(name))
+
+	if m == nil {
+		return versionName{}, false
+	}
+
+	var number []int
+
+	for _, n := range strings.Split(m[2], ".") {
+		v, err := strconv.Atoi(n)
+
+		if err != nil {
+			return versionName{}, false
+		}
+
+		number = append(number, v)
+	}
+
+	// Patch level is not a part of variation
+	variation := patchLevelRegex.ReplaceAllString(m[3], "")
+
+	return versionName{m[1], number, variation}, true
+}
+
+// isNewer returns true if first version is newer than second
+func isNewer(v1, v2 versionName, name1, name2 string) bool {
+	switch compareNumbers(v1.Number, v2.Number) {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+
+	return !sortutil.VersionCompare(name1, name2)
+}
+
+// hasNumberPrefix returns true if version number starts with given prefix
+func hasNumberPrefix(number, prefix []int) bool {
+	if len(prefix) > len(number) {
+		return compareNumbers(number, prefix) == 0
+	}
+
+	for i := range prefix {
+		if number[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareNumbers compares two version numbers
+func compareNumbers(n1, n2 []int) int {
+	for i := 0; i < max(len(n1), len(n2)); i++ {
+		var v1, v2 int
+
+		if i < len(n1) {
+			v1 = n1[i]
+		}
+
+		if i < len(n2) {
+			v2 = n2[i]
+		}
+
+		switch {
+		case v1 > v2:
+			return 1
+		case v1 < v2:
+			return -1
+		}
+	}
+
+	return 0
+}
+
+// getPessimisticLimit returns upper limit for pessimistic constraint
+// (~> 3.2 → 4, ~> 3.2.1 → 3.3)
+func getPessimisticLimit(number []int) []int {
+	if len(number) == 1 {
+		return []int{number[0] + 1}
+	}
+
+	limit := append([]int{}, number[:len(number)-1]...)
+	limit[len(limit)-1]++
+
+	return limit
+}
@@ -0,0 +1,258 @@
+package index
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		Constraint string
+		Parts      int
+		IsValid    bool
+	}{
+		{"3.3", 1, true},
+		{"3.3.5", 1, true},
+		{"~> 3.2", 1, true},
+		{"~>3.2.1", 1, true},
+		{">= 3.1, < 3.3", 2, true},
+		{"!= 3.2.0", 1, true},
+		{"jruby-9.4", 1, true},
+		{"3.3-jemalloc", 1, true},
+		{"", 0, false},
+		{"3.3,", 0, false},
+		{">=", 0, false},
+		{"latest", 0, false},
+		{"~> abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.Constraint)
+
+		switch {
+		case tt.IsValid && err != nil:
+			t.Errorf("ParseConstraint(%q) returned error: %v", tt.Constraint, err)
+		case !tt.IsValid && err == nil:
+			t.Errorf("ParseConstraint(%q) must return error", tt.Constraint)
+		case len(c) != tt.Parts:
+			t.Errorf("ParseConstraint(%q) returned %d parts, want %d", tt.Constraint, len(c), tt.Parts)
+		}
+	}
+}
+
+func TestConstraintMatch(t *testing.T) {
+	tests := []struct {
+		Constraint string
+		Name       string
+		IsMatch    bool
+	}{
+		{"3.3", "3.3.5", true},
+		{"3.3", "3.3", true},
+		{"3.3", "3.4.1", false},
+		{"3.3", "3.3.5-jemalloc", false},
+		{"3.3", "3.30.1", false},
+		{"3.3.5", "3.3.5", true},
+		{"3.3.5", "3.3.5-p100", true},
+		{"= 3.3", "3.3.0", true},
+		{"= 3.3", "3.3.1", false},
+		{"!= 3.3.0", "3.3.1", true},
+		{"!= 3.3.0", "3.3.0", false},
+		{"> 3.2", "3.2.1", true},
+		{"> 3.2", "3.2.0", false},
+		{">= 3.2", "3.2.0", true},
+		{"< 3.3", "3.2.9", true},
+		{"< 3.3", "3.3.0", false},
+		{"<= 3.3", "3.3.0", true},
+		{"~> 3.2", "3.2.0", true},
+		{"~> 3.2", "3.9.1", true},
+		{"~> 3.2", "4.0.0", false},
+		{"~> 3.2.1", "3.2.5", true},
+		{"~> 3.2.1", "3.2.0", false},
+		{"~> 3.2.1", "3.3.0", false},
+		{">= 3.1, < 3.3", "3.2.4", true},
+		{">= 3.1, < 3.3", "3.3.0", false},
+		{">= 3.1, < 3.3", "3.0.7", false},
+		{"jruby-9.4", "jruby-9.4.8.0", true},
+		{"jruby-9.4", "9.4.8", false},
+		{"3.3-jemalloc", "3.3.5-jemalloc", true},
+		{"3.3-jemalloc", "3.3.5", false},
+		{"3.3", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.Constraint)
+
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) returned error: %v", tt.Constraint, err)
+		}
+
+		if c.Match(tt.Name) != tt.IsMatch {
+			t.Errorf("Constraint %q matching %q must be %t", tt.Constraint, tt.Name, tt.IsMatch)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	i := getTestIndex()
+
+	tests := []struct {
+		Constraint string
+		EOL        bool
+		Name       string
+		Category   string
+		IsValid    bool
+	}{
+		{"3.3", false, "3.3.6", CATEGORY_RUBY, true},
+		{"3", false, "3.3.6", CATEGORY_RUBY, true},
+		{"~> 3.2.0", false, "3.2.6", CATEGORY_RUBY, true},
+		{"< 3.3", false, "3.2.6", CATEGORY_RUBY, true},
+		{"3.3-jemalloc", false, "3.3.6-jemalloc", CATEGORY_RUBY, true},
+		{"2.7", false, "", "", true},
+		{"2.7", true, "2.7.8", CATEGORY_RUBY, true},
+		{"2.7-jemalloc", false, "", "", true},
+		{"2.7-jemalloc", true, "2.7.8-jemalloc", CATEGORY_RUBY, true},
+		{"jruby-9", false, "jruby-9.4.8.0", CATEGORY_JRUBY, true},
+		{"4.0", false, "", "", true},
+		{"latest", false, "", "", false},
+	}
+
+	for _, tt := range tests {
+		info, category, err := i.Resolve("el8", "x86_64", tt.Constraint, tt.EOL)
+
+		switch {
+		case !tt.IsValid:
+			if err == nil {
+				t.Errorf("Resolve(%q) must return error", tt.Constraint)
+			}
+		case err != nil:
+			t.Errorf("Resolve(%q) returned error: %v", tt.Constraint, err)
+		case tt.Name == "" && info != nil:
+			t.Errorf("Resolve(%q) returned %s, want nothing", tt.Constraint, info.Name)
+		case tt.Name != "" && info == nil:
+			t.Errorf("Resolve(%q) returned nothing, want %s", tt.Constraint, tt.Name)
+		case info != nil && (info.Name != tt.Name || category != tt.Category):
+			t.Errorf(
+				"Resolve(%q) returned %s (%s), want %s (%s)",
+				tt.Constraint, info.Name, category, tt.Name, tt.Category,
+			)
+		}
+	}
+
+	info, _, err := i.Resolve("el9", "x86_64", "3.3", false)
+
+	if info != nil || err != nil {
+		t.Errorf("Resolve for unknown dist must return nothing")
+	}
+
+	info, _, err = i.Resolve("centos8", "x86_64", "3.3", false)
+
+	if err != nil || info == nil || info.Name != "3.3.6" {
+		t.Errorf("Resolve must support dist aliases")
+	}
+
+	var nilIndex *Index
+
+	info, _, err = nilIndex.Resolve("el8", "x86_64", "3.3", false)
+
+	if info != nil || err != nil {
+		t.Errorf("Resolve for nil index must return nothing")
+	}
+}
+
+func TestFindUpgrade(t *testing.T) {
+	i := getTestIndex()
+
+	tests := []struct {
+		Name    string
+		Upgrade string
+	}{
+		{"3.3.4", "3.3.6"},
+		{"3.3.5", "3.3.6"},
+		{"3.3.6", ""},
+		{"3.3.4-jemalloc", "3.3.6-jemalloc"},
+		{"3.2.2", "3.2.6"},
+		{"2.7.1", "2.7.8"},
+		{"jruby-9.4.5.0", "jruby-9.4.8.0"},
+		{"3.4.1", ""},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		info, _ := i.FindUpgrade("el8", "x86_64", tt.Name)
+
+		switch {
+		case tt.Upgrade == "" && info != nil:
+			t.Errorf("FindUpgrade(%q) returned %s, want nothing", tt.Name, info.Name)
+		case tt.Upgrade != "" && info == nil:
+			t.Errorf("FindUpgrade(%q) returned nothing, want %s", tt.Name, tt.Upgrade)
+		case info != nil && info.Name != tt.Upgrade:
+			t.Errorf("FindUpgrade(%q) returned %s, want %s", tt.Name, info.Name, tt.Upgrade)
+		}
+	}
+
+	var nilIndex *Index
+
+	if info, _ := nilIndex.FindUpgrade("el8", "x86_64", "3.3.4"); info != nil {
+		t.Errorf("FindUpgrade for nil index must return nothing")
+	}
+}
+
+func TestIsSuperseded(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Other        string
+		IsSuperseded bool
+	}{
+		{"3.3.4", "3.3.6", true},
+		{"3.3.6", "3.3.4", false},
+		{"3.3.6", "3.3.6", false},
+		{"3.2.6", "3.3.0", false},
+		{"3.3.4", "3.3.6-jemalloc", false},
+		{"3.3.4-jemalloc", "3.3.6-jemalloc", true},
+		{"2.6.10", "2.6.10-p1", true},
+		{"jruby-9.4.5.0", "jruby-9.4.8.0", true},
+		{"jruby-9.4.5.0", "9.4.8", false},
+		{"3", "3.1", false},
+		{"3.3.4", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		if IsSuperseded(tt.Name, tt.Other) != tt.IsSuperseded {
+			t.Errorf("IsSuperseded(%q, %q) must be %t", tt.Name, tt.Other, tt.IsSuperseded)
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getTestIndex returns index with test data
+func getTestIndex() *Index {
+	i := NewIndex()
+	i.Aliases = map[string]string{"centos8": "el8"}
+
+	i.Add("el8", "x86_64", CATEGORY_RUBY, &VersionInfo{
+		Name: "2.7.8", EOL: true,
+		Variations: []*VersionInfo{{Name: "2.7.8-jemalloc"}},
+	})
+
+	i.Add("el8", "x86_64", CATEGORY_RUBY, &VersionInfo{Name: "3.2.5"})
+	i.Add("el8", "x86_64", CATEGORY_RUBY, &VersionInfo{Name: "3.2.6"})
+	i.Add("el8", "x86_64", CATEGORY_RUBY, &VersionInfo{Name: "3.3.5"})
+
+	i.Add("el8", "x86_64", CATEGORY_RUBY, &VersionInfo{
+		Name:       "3.3.6",
+		Variations: []*VersionInfo{{Name: "3.3.6-jemalloc"}},
+	})
+
+	i.Add("el8", "x86_64", CATEGORY_JRUBY, &VersionInfo{Name: "jruby-9.4.8.0"})
+
+	return i
+}
@@ -63,6 +63,8 @@ type VersionInfo struct {
 	File       string         `json:"file"`                 // Full filename (with extension)
 	Path       string         `json:"path"`                 // Relative path to file
 	Hash       string         `json:"hash"`                 // SHA-256 hash
+	Signature  string         `json:"sig,omitempty"`        // Ed25519 signature of file path and hash
+	Manifest   string         `json:"manifest,omitempty"`   // SHA-256 hash of file manifest
 	Size       int64          `json:"size"`                 // Size in bytes
 	Added      int64          `json:"added"`                // Timestamp with date when version was added to repo
 	EOL        bool           `json:"eol"`                  // EOL marker
@@ -265,6 +267,17 @@ func (i *Index) Find(dist, arch, name string) (*VersionInfo, string) {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// SignatureData returns data used for archive signing
+func (v *VersionInfo) SignatureData() []byte {
+	if v == nil {
+		return nil
+	}
+
+	return []byte(v.Path + "/" + v.File + ":" + v.Hash)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // Keys returns sorted slice with keys
 func (d Data) Keys() []string {
 	if len(d) == 0 {
@@ -0,0 +1,198 @@
+package index
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/essentialkaos/ek/v13/hashutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// MANIFEST_EXTENSION is extension of archive manifest file
+const MANIFEST_EXTENSION = ".manifest"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Manifest contains info about all files in archive
+type Manifest struct {
+	Version string          `json:"version"`
+	Files   []*ManifestFile `json:"files"`
+}
+
+// ManifestFile contains info about file in archive
+type ManifestFile struct {
+	Path string `json:"path"`           // Path relative to version directory
+	Mode string `json:"mode"`           // Permissions in octal form
+	Hash string `json:"hash,omitempty"` // SHA-256 hash of regular file
+	Link string `json:"link,omitempty"` // Target of symbolic link
+}
+
+// ManifestDiff contains difference between two manifests
+type ManifestDiff struct {
+	Modified []string `json:"modified,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+	Extra    []string `json:"extra,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// CreateManifest creates manifest for files in given directory
+func CreateManifest(version, dir string) (*Manifest, error) {
+	m := &Manifest{Version: version}
+
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(dir, file)
+		fileInfo, err := d.Info()
+
+		if err != nil {
+			return err
+		}
+
+		mf := &ManifestFile{
+			Path: relPath,
+			Mode: fmt.Sprintf("%04o", fileInfo.Mode().Perm()),
+		}
+
+		switch {
+		case fileInfo.Mode()&os.ModeSymlink != 0:
+			mf.Link, err = os.Readlink(file)
+
+			if err != nil {
+				return err
+			}
+		case fileInfo.Mode().IsRegular():
+			mf.Hash = hashutil.File(file, sha256.New()).String()
+
+			if mf.Hash == "" {
+				return fmt.Errorf("Can't calculate hash of file %s", file)
+			}
+		default:
+			return nil
+		}
+
+		m.Files = append(m.Files, mf)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+
+	return m, nil
+}
+
+// DecodeManifest decodes manifest from JSON data
+func DecodeManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	err := json.Unmarshal(data, m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Encode encodes manifest to JSON format
+func (m *Manifest) Encode() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("Manifest is nil")
+	}
+
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Diff compares manifest with manifest of actual files
+func (m *Manifest) Diff(actual *Manifest) *ManifestDiff {
+	diff := &ManifestDiff{}
+
+	if m == nil || actual == nil {
+		return diff
+	}
+
+	actualFiles := make(map[string]*ManifestFile, len(actual.Files))
+
+	for _, file := range actual.Files {
+		actualFiles[file.Path] = file
+	}
+
+	for _, file := range m.Files {
+		actualFile, ok := actualFiles[file.Path]
+
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, file.Path)
+		case !file.IsSame(actualFile):
+			diff.Modified = append(diff.Modified, file.Path)
+		}
+
+		delete(actualFiles, file.Path)
+	}
+
+	for _, file := range actual.Files {
+		if actualFiles[file.Path] != nil {
+			diff.Extra = append(diff.Extra, file.Path)
+		}
+	}
+
+	return diff
+}
+
+// IsSame returns true if files have the same content and executable bits
+// (other permissions can be changed by umask or package managers)
+func (f *ManifestFile) IsSame(other *ManifestFile) bool {
+	if f == nil || other == nil {
+		return f == other
+	}
+
+	return f.Path == other.Path && f.Hash == other.Hash && f.Link == other.Link &&
+		getExecBits(f.Mode) == getExecBits(other.Mode)
+}
+
+// IsEmpty returns true if there is no difference between manifests
+func (d *ManifestDiff) IsEmpty() bool {
+	return d == nil || len(d.Modified)+len(d.Missing)+len(d.Extra) == 0
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getExecBits returns executable bits of permissions in octal form
+func getExecBits(mode string) int64 {
+	perms, err := strconv.ParseInt(mode, 8, 32)
+
+	if err != nil {
+		return -1
+	}
+
+	return perms & 0111
+}
@@ -0,0 +1,232 @@
+package index
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestCreateManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+	os.WriteFile(filepath.Join(dir, "bin", "ruby"), []byte("ruby"), 0755)
+	os.WriteFile(filepath.Join(dir, "lib", "test.rb"), []byte("test"), 0644)
+	os.Symlink("ruby", filepath.Join(dir, "bin", "ruby3"))
+
+	m, err := CreateManifest("3.3.6", dir)
+
+	if err != nil {
+		t.Fatalf("CreateManifest returned error: %v", err)
+	}
+
+	if m.Version != "3.3.6" {
+		t.Errorf("Manifest has wrong version %q", m.Version)
+	}
+
+	tests := []struct {
+		Path string
+		Mode string
+		Link string
+	}{
+		{"bin/ruby", "0755", ""},
+		{"bin/ruby3", "0777", "ruby"},
+		{"lib/test.rb", "0644", ""},
+	}
+
+	if len(m.Files) != len(tests) {
+		t.Fatalf("Manifest contains %d files, want %d", len(m.Files), len(tests))
+	}
+
+	for i, tt := range tests {
+		f := m.Files[i]
+
+		switch {
+		case f.Path != tt.Path:
+			t.Errorf("File #%d has path %q, want %q", i, f.Path, tt.Path)
+		case f.Link != tt.Link:
+			t.Errorf("File %s has link %q, want %q", f.Path, f.Link, tt.Link)
+		case tt.Link == "" && f.Mode != tt.Mode:
+			t.Errorf("File %s has mode %q, want %q", f.Path, f.Mode, tt.Mode)
+		case tt.Link == "" && f.Hash == "":
+			t.Errorf("File %s has no hash", f.Path)
+		case tt.Link != "" && f.Hash != "":
+			t.Errorf("Link %s must not have hash", f.Path)
+		}
+	}
+
+	// SHA-256 hash of "test"
+	if m.Files[2].Hash != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("File %s has wrong hash %q", m.Files[2].Path, m.Files[2].Hash)
+	}
+
+	_, err = CreateManifest("3.3.6", filepath.Join(dir, "unknown"))
+
+	if err == nil {
+		t.Errorf("CreateManifest for unknown directory must return error")
+	}
+}
+
+func TestManifestEncoding(t *testing.T) {
+	m := &Manifest{
+		Version: "3.3.6",
+		Files: []*ManifestFile{
+			{Path: "bin/ruby", Mode: "0755", Hash: "abcd"},
+			{Path: "bin/ruby3", Mode: "0777", Link: "ruby"},
+		},
+	}
+
+	data, err := m.Encode()
+
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	decoded, err := DecodeManifest(data)
+
+	if err != nil {
+		t.Fatalf("DecodeManifest returned error: %v", err)
+	}
+
+	if decoded.Version != m.Version || len(decoded.Files) != len(m.Files) {
+		t.Fatalf("Decoded manifest doesn't match original")
+	}
+
+	for i, f := range decoded.Files {
+		if *f != *m.Files[i] {
+			t.Errorf("Decoded file %s doesn't match original", f.Path)
+		}
+	}
+
+	var nilManifest *Manifest
+
+	if _, err = nilManifest.Encode(); err == nil {
+		t.Errorf("Encode for nil manifest must return error")
+	}
+
+	if _, err = DecodeManifest([]byte("{")); err == nil {
+		t.Errorf("DecodeManifest for malformed data must return error")
+	}
+}
+
+func TestManifestDiff(t *testing.T) {
+	original := &Manifest{
+		Files: []*ManifestFile{
+			{Path: "bin/ruby", Mode: "0755", Hash: "1111"},
+			{Path: "bin/ruby3", Mode: "0777", Link: "ruby"},
+			{Path: "lib/a.rb", Mode: "0644", Hash: "2222"},
+			{Path: "lib/b.rb", Mode: "0644", Hash: "3333"},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Actual   []*ManifestFile
+		Modified []string
+		Missing  []string
+		Extra    []string
+	}{
+		{
+			"same files", []*ManifestFile{
+				{Path: "bin/ruby", Mode: "0755", Hash: "1111"},
+				{Path: "bin/ruby3", Mode: "0777", Link: "ruby"},
+				{Path: "lib/a.rb", Mode: "0644", Hash: "2222"},
+				{Path: "lib/b.rb", Mode: "0644", Hash: "3333"},
+			}, nil, nil, nil,
+		},
+		{
+			"changed non-executable bits", []*ManifestFile{
+				{Path: "bin/ruby", Mode: "0775", Hash: "1111"},
+				{Path: "bin/ruby3", Mode: "0777", Link: "ruby"},
+				{Path: "lib/a.rb", Mode: "0600", Hash: "2222"},
+				{Path: "lib/b.rb", Mode: "0664", Hash: "3333"},
+			}, nil, nil, nil,
+		},
+		{
+			"changed executable bits", []*ManifestFile{
+				{Path: "bin/ruby", Mode: "0644", Hash: "1111"},
+				{Path: "bin/ruby3", Mode: "0777", Link: "ruby"},
+				{Path: "lib/a.rb", Mode: "0744", Hash: "2222"},
+				{Path: "lib/b.rb", Mode: "0644", Hash: "3333"},
+			}, []string{"bin/ruby", "lib/a.rb"}, nil, nil,
+		},
+		{
+			"changed content", []*ManifestFile{
+				{Path: "bin/ruby", Mode: "0755", Hash: "1111"},
+				{Path: "bin/ruby3", Mode: "0777", Link: "ruby2"},
+				{Path: "lib/a.rb", Mode: "0644", Hash: "9999"},
+				{Path: "lib/b.rb", Mode: "0644", Hash: "3333"},
+			}, []string{"bin/ruby3", "lib/a.rb"}, nil, nil,
+		},
+		{
+			"missing and extra files", []*ManifestFile{
+				{Path: "bin/ruby", Mode: "0755", Hash: "1111"},
+				{Path: "bin/rake", Mode: "0755", Hash: "4444"},
+				{Path: "lib/a.rb", Mode: "0644", Hash: "2222"},
+			}, nil, []string{"bin/ruby3", "lib/b.rb"}, []string{"bin/rake"},
+		},
+	}
+
+	for _, tt := range tests {
+		diff := original.Diff(&Manifest{Files: tt.Actual})
+
+		if !slices.Equal(diff.Modified, tt.Modified) {
+			t.Errorf("[%s] Modified files are %v, want %v", tt.Name, diff.Modified, tt.Modified)
+		}
+
+		if !slices.Equal(diff.Missing, tt.Missing) {
+			t.Errorf("[%s] Missing files are %v, want %v", tt.Name, diff.Missing, tt.Missing)
+		}
+
+		if !slices.Equal(diff.Extra, tt.Extra) {
+			t.Errorf("[%s] Extra files are %v, want %v", tt.Name, diff.Extra, tt.Extra)
+		}
+
+		isEmpty := len(tt.Modified)+len(tt.Missing)+len(tt.Extra) == 0
+
+		if diff.IsEmpty() != isEmpty {
+			t.Errorf("[%s] IsEmpty must be %t", tt.Name, isEmpty)
+		}
+	}
+
+	var nilManifest *Manifest
+
+	if !nilManifest.Diff(original).IsEmpty() {
+		t.Errorf("Diff for nil manifest must be empty")
+	}
+}
+
+func TestManifestFileIsSame(t *testing.T) {
+	tests := []struct {
+		File1  *ManifestFile
+		File2  *ManifestFile
+		IsSame bool
+	}{
+		{&ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, &ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, true},
+		{&ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, &ManifestFile{Path: "a", Mode: "0711", Hash: "1"}, true},
+		{&ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, &ManifestFile{Path: "a", Mode: "0754", Hash: "1"}, false},
+		{&ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, &ManifestFile{Path: "a", Mode: "0755", Hash: "2"}, false},
+		{&ManifestFile{Path: "a", Mode: "0755", Hash: "1"}, &ManifestFile{Path: "b", Mode: "0755", Hash: "1"}, false},
+		{&ManifestFile{Path: "a", Mode: "0777", Link: "b"}, &ManifestFile{Path: "a", Mode: "0777", Link: "c"}, false},
+		{&ManifestFile{Path: "a", Mode: "0644", Hash: "1"}, &ManifestFile{Path: "a", Mode: "abc", Hash: "1"}, false},
+		{&ManifestFile{Path: "a", Mode: "0644", Hash: "1"}, nil, false},
+		{nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		if tt.File1.IsSame(tt.File2) != tt.IsSame {
+			t.Errorf("IsSame(%v, %v) must be %t", tt.File1, tt.File2, tt.IsSame)
+		}
+	}
+}
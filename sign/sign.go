@@ -0,0 +1,112 @@
+package sign
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EXTENSION is extension of detached signature file
+const EXTENSION = ".sig"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	// ErrNoKeys is returned if there are no public keys for verification
+	ErrNoKeys = errors.New("There are no public keys for signature verification")
+
+	// ErrEmptySignature is returned if signature is empty
+	ErrEmptySignature = errors.New("Signature is empty")
+
+	// ErrInvalidSignature is returned if signature doesn't match any of given keys
+	ErrInvalidSignature = errors.New("Signature doesn't match any of trusted public keys")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GenerateKeys generates new pair of public and private keys encoded with Base64
+func GenerateKeys() (string, string, error) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return "", "", fmt.Errorf("Can't generate keys: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(pubKey),
+		base64.StdEncoding.EncodeToString(privKey), nil
+}
+
+// ReadPrivateKey reads Base64-encoded private key from given file
+func ReadPrivateKey(file string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read private key: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode private key: %w", err)
+	}
+
+	switch len(key) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	}
+
+	return nil, fmt.Errorf("Private key has wrong size (%d)", len(key))
+}
+
+// Sign signs given data and returns Base64-encoded signature
+func Sign(data []byte, key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+}
+
+// Verify verifies data signature using given Base64-encoded public keys
+func Verify(data []byte, signature string, keys []string) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	signature = strings.TrimSpace(signature)
+
+	if signature == "" {
+		return ErrEmptySignature
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+
+	if err != nil {
+		return fmt.Errorf("Can't decode signature: %w", err)
+	}
+
+	for _, key := range keys {
+		pubKey, err := base64.StdEncoding.DecodeString(key)
+
+		if err != nil || len(pubKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("Public key %q is malformed", key)
+		}
+
+		if ed25519.Verify(ed25519.PublicKey(pubKey), data, sig) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
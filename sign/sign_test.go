@@ -0,0 +1,128 @@
+package sign
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func TestSignAndVerify(t *testing.T) {
+	pubKey, privKey := genTestKeys(t)
+	otherPubKey, _ := genTestKeys(t)
+
+	data := []byte("{\"meta\":{\"created\":1}}")
+	signature := Sign(data, readTestKey(t, privKey))
+
+	err := Verify(data, signature+"\n", []string{pubKey})
+
+	if err != nil {
+		t.Fatalf("Verify returned error for valid signature: %v", err)
+	}
+
+	err = Verify(data, signature, []string{otherPubKey, pubKey})
+
+	if err != nil {
+		t.Errorf("Verify must accept signature made by any of given keys: %v", err)
+	}
+
+	tests := []struct {
+		Name      string
+		Data      []byte
+		Signature string
+		Keys      []string
+		Error     error
+	}{
+		{"tampered data", []byte("{\"meta\":{\"created\":2}}"), signature, []string{pubKey}, ErrInvalidSignature},
+		{"wrong key", data, signature, []string{otherPubKey}, ErrInvalidSignature},
+		{"no keys", data, signature, nil, ErrNoKeys},
+		{"empty signature", data, " \n", []string{pubKey}, ErrEmptySignature},
+		{"malformed signature", data, "!!!", []string{pubKey}, nil},
+		{"short signature", data, base64.StdEncoding.EncodeToString([]byte("abcd")), []string{pubKey}, ErrInvalidSignature},
+		{"malformed key", data, signature, []string{"abcd"}, nil},
+	}
+
+	for _, tt := range tests {
+		err := Verify(tt.Data, tt.Signature, tt.Keys)
+
+		switch {
+		case err == nil:
+			t.Errorf("[%s] Verify must return error", tt.Name)
+		case tt.Error != nil && !errors.Is(err, tt.Error):
+			t.Errorf("[%s] Verify returned error %q, want %q", tt.Name, err, tt.Error)
+		}
+	}
+}
+
+func TestReadPrivateKey(t *testing.T) {
+	_, privKey := genTestKeys(t)
+
+	key, _ := base64.StdEncoding.DecodeString(privKey)
+	seed := base64.StdEncoding.EncodeToString(key[:ed25519.SeedSize])
+
+	if !readTestKey(t, seed).Equal(readTestKey(t, privKey)) {
+		t.Errorf("Key read from seed doesn't match original key")
+	}
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		Name string
+		Data string
+	}{
+		{"malformed key", "not-a-key!"},
+		{"short key", base64.StdEncoding.EncodeToString([]byte("abcd"))},
+		{"empty key", ""},
+	}
+
+	for _, tt := range tests {
+		file := filepath.Join(dir, "key")
+		os.WriteFile(file, []byte(tt.Data), 0600)
+
+		if _, err := ReadPrivateKey(file); err == nil {
+			t.Errorf("[%s] ReadPrivateKey must return error", tt.Name)
+		}
+	}
+
+	if _, err := ReadPrivateKey(filepath.Join(dir, "unknown")); err == nil {
+		t.Errorf("ReadPrivateKey for unknown file must return error")
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// genTestKeys generates new pair of keys
+func genTestKeys(t *testing.T) (string, string) {
+	pubKey, privKey, err := GenerateKeys()
+
+	if err != nil {
+		t.Fatalf("GenerateKeys returned error: %v", err)
+	}
+
+	return pubKey, privKey
+}
+
+// readTestKey writes given key to file and reads it using ReadPrivateKey
+func readTestKey(t *testing.T, data string) ed25519.PrivateKey {
+	file := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(file, []byte(data+"\n"), 0600)
+
+	key, err := ReadPrivateKey(file)
+
+	if err != nil {
+		t.Fatalf("ReadPrivateKey returned error: %v", err)
+	}
+
+	return key
+}